@@ -0,0 +1,90 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/twpayne/go-geos"
+)
+
+func TestGeometryArrayCodec(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, geomType := range geomTypes {
+			t.Run(geomType, func(t *testing.T) {
+				for _, format := range []int16{
+					pgx.BinaryFormatCode,
+					pgx.TextFormatCode,
+				} {
+					tb.(*testing.T).Run(strconv.Itoa(int(format)), func(t *testing.T) { //nolint:forcetypeassert
+						geoms := []*geos.Geom{
+							mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(4326),
+							nil,
+							mustNewGeomFromWKT(t, "POINT(3 4)").SetSRID(4326),
+						}
+						var actual []*geos.Geom
+						assert.NoError(t, conn.QueryRow(ctx, "select $1::"+geomType+"[]", pgx.QueryResultFormats{format}, geoms).Scan(&actual))
+						assert.Equal(t, len(geoms), len(actual))
+						assert.Equal(t, geoms[0].ToEWKBWithSRID(), actual[0].ToEWKBWithSRID())
+						assert.Zero(t, actual[1])
+						assert.Equal(t, geoms[2].ToEWKBWithSRID(), actual[2].ToEWKBWithSRID())
+					})
+				}
+			})
+		}
+	})
+}
+
+func TestGeometryArrayCodecArrayAgg(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		var actual []*geos.Geom
+		assert.NoError(tb, conn.QueryRow(ctx, "select array_agg(ST_MakePoint(i, i)) from generate_series(1, 3) as i").Scan(&actual))
+		assert.Equal(tb, 3, len(actual))
+		for i, geom := range actual {
+			assert.Equal(tb, mustNewGeomFromWKT(tb, "POINT("+strconv.Itoa(i+1)+" "+strconv.Itoa(i+1)+")").ToEWKBWithSRID(), geom.ToEWKBWithSRID())
+		}
+	})
+}
+
+func TestGeometryArrayCodecAny(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		geoms := []*geos.Geom{
+			mustNewGeomFromWKT(tb, "POINT(1 2)"),
+			mustNewGeomFromWKT(tb, "POINT(3 4)"),
+		}
+		var actual bool
+		assert.NoError(tb, conn.QueryRow(ctx, "select 'POINT(3 4)'::geometry = any($1)", geoms).Scan(&actual))
+		assert.True(tb, actual)
+	})
+}
+
+func TestBox2DArrayCodec(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		box2Ds := []geos.Box2D{
+			*geos.NewBox2D(1, 2, 3, 4),
+			*geos.NewBox2D(5, 6, 7, 8),
+		}
+		var actual []geos.Box2D
+		assert.NoError(tb, conn.QueryRow(ctx, "select $1::box2d[]", box2Ds).Scan(&actual))
+		assert.Equal(tb, box2Ds, actual)
+	})
+}
+
+func TestBox3DArrayCodec(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		box3Ds := []geos.Box3D{
+			*geos.NewBox3D(1, 2, 3, 4, 5, 6),
+			*geos.NewBox3D(7, 8, 9, 10, 11, 12),
+		}
+		var actual []geos.Box3D
+		assert.NoError(tb, conn.QueryRow(ctx, "select $1::box3d[]", box3Ds).Scan(&actual))
+		assert.Equal(tb, box3Ds, actual)
+	})
+}
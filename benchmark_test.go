@@ -0,0 +1,190 @@
+package pgxgeos_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+const geometryTestOID = 100003
+
+var formatNames = map[int16]string{
+	pgx.BinaryFormatCode: "binary",
+	pgx.TextFormatCode:   "text",
+}
+
+func newGeometryTestMap(opts ...pgxgeos.Option) *pgtype.Map {
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Name: "geometry", OID: geometryTestOID, Codec: pgxgeos.NewGeometryCodec(geos.DefaultContext, opts...)})
+	return m
+}
+
+// newLineStringEWKB returns a little-endian EWKB LineString with n points and
+// no SRID.
+func newLineStringEWKB(n int) pgxgeos.EWKB {
+	ewkb := make([]byte, 0, 9+16*n)
+	ewkb = append(ewkb, 1)
+	ewkb = binary.LittleEndian.AppendUint32(ewkb, uint32(pgxgeos.GeometryTypeLineString))
+	ewkb = binary.LittleEndian.AppendUint32(ewkb, uint32(n))
+	for i := range n {
+		ewkb = binary.LittleEndian.AppendUint64(ewkb, math.Float64bits(float64(i)))
+		ewkb = binary.LittleEndian.AppendUint64(ewkb, math.Float64bits(float64(-i)))
+	}
+	return ewkb
+}
+
+// encodeSrc returns ewkb as it is received from PostgreSQL in format.
+func encodeSrc(ewkb []byte, format int16) []byte {
+	if format == pgx.TextFormatCode {
+		return hex.AppendEncode(nil, ewkb)
+	}
+	return ewkb
+}
+
+// TestGeometryEncodeAllocs tests that values that are already EWKB are
+// appended directly to pgx's buffer.
+func TestGeometryEncodeAllocs(t *testing.T) {
+	ewkb := newLineStringEWKB(100)
+	for _, tc := range []struct {
+		name string
+		opts []pgxgeos.Option
+	}{
+		{
+			name: "default",
+		},
+		{
+			name: "default_srid",
+			opts: []pgxgeos.Option{pgxgeos.WithDefaultSRID(4326)},
+		},
+	} {
+		for format, formatName := range formatNames {
+			m := newGeometryTestMap(tc.opts...)
+			var lazyGeom pgxgeos.LazyGeom
+			assert.NoError(t, m.Scan(geometryTestOID, pgx.BinaryFormatCode, ewkb, &lazyGeom))
+			for _, value := range []struct {
+				name  string
+				value any
+			}{
+				{name: "ewkb", value: &ewkb},
+				{name: "lazy", value: &lazyGeom},
+			} {
+				t.Run(tc.name+"/"+formatName+"/"+value.name, func(t *testing.T) {
+					buf, err := m.Encode(geometryTestOID, format, value.value, nil)
+					assert.NoError(t, err)
+					allocs := testing.AllocsPerRun(100, func() {
+						buf, _ = m.Encode(geometryTestOID, format, value.value, buf[:0])
+					})
+					assert.Equal(t, 0, allocs)
+				})
+			}
+		}
+	}
+}
+
+// TestGeometryEncodeGeomAllocs tests that encoding a *geos.Geom allocates,
+// as GEOS cannot write EWKB into pgx's buffer.
+func TestGeometryEncodeGeomAllocs(t *testing.T) {
+	geom, err := geos.NewGeomFromWKB(newLineStringEWKB(100))
+	assert.NoError(t, err)
+	m := newGeometryTestMap()
+	buf, err := m.Encode(geometryTestOID, pgx.BinaryFormatCode, geom, nil)
+	assert.NoError(t, err)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = m.Encode(geometryTestOID, pgx.BinaryFormatCode, geom, buf[:0])
+	})
+	assert.True(t, allocs >= 1)
+}
+
+func TestGeometryScanAllocs(t *testing.T) {
+	ewkb := newLineStringEWKB(100)
+	m := newGeometryTestMap()
+	allocs := make(map[int16]float64)
+	for format := range formatNames {
+		src := encodeSrc(ewkb, format)
+		var box2D geos.Box2D
+		assert.NoError(t, m.Scan(geometryTestOID, format, src, &box2D))
+		assert.Equal(t, *geos.NewBox2D(0, -99, 99, 0), box2D)
+		allocs[format] = testing.AllocsPerRun(100, func() {
+			_ = m.Scan(geometryTestOID, format, src, &box2D)
+		})
+	}
+	assert.Equal(t, allocs[pgx.BinaryFormatCode], allocs[pgx.TextFormatCode])
+}
+
+func BenchmarkGeometryEncode(b *testing.B) {
+	ewkb := newLineStringEWKB(100)
+	geom, err := geos.NewGeomFromWKB(ewkb)
+	assert.NoError(b, err)
+	var lazyGeom pgxgeos.LazyGeom
+	assert.NoError(b, newGeometryTestMap().Scan(geometryTestOID, pgx.BinaryFormatCode, ewkb, &lazyGeom))
+	for _, value := range []struct {
+		name  string
+		value any
+	}{
+		{name: "geom", value: geom},
+		{name: "ewkb", value: &ewkb},
+		{name: "lazy", value: &lazyGeom},
+	} {
+		for format, formatName := range formatNames {
+			b.Run(value.name+"/"+formatName, func(b *testing.B) {
+				m := newGeometryTestMap(pgxgeos.WithDefaultSRID(4326))
+				var buf []byte
+				b.ReportAllocs()
+				for b.Loop() {
+					var err error
+					if buf, err = m.Encode(geometryTestOID, format, value.value, buf[:0]); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkGeometryScan(b *testing.B) {
+	ewkb := newLineStringEWKB(100)
+	for _, target := range []struct {
+		name   string
+		target any
+	}{
+		{name: "geom", target: new(*geos.Geom)},
+		{name: "ewkb", target: new(pgxgeos.EWKB)},
+		{name: "box2d", target: new(geos.Box2D)},
+	} {
+		for format, formatName := range formatNames {
+			b.Run(target.name+"/"+formatName, func(b *testing.B) {
+				m := newGeometryTestMap()
+				src := encodeSrc(ewkb, format)
+				b.ReportAllocs()
+				for b.Loop() {
+					if err := m.Scan(geometryTestOID, format, src, target.target); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkEWKBWriterEncode(b *testing.B) {
+	m := newGeometryTestMap()
+	w := pgxgeos.NewEWKBWriter(4326)
+	var ewkb pgxgeos.EWKB
+	var buf []byte
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		ewkb = w.AppendPointXY(ewkb[:0], float64(i), -float64(i))
+		var err error
+		if buf, err = m.Encode(geometryTestOID, pgx.BinaryFormatCode, &ewkb, buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,33 +1,39 @@
 package pgxgeos
 
 import (
-	"context"
 	"database/sql/driver"
 	"errors"
 	"fmt"
-	"regexp"
-	"strconv"
-	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/twpayne/go-geos"
 )
 
-var box2DRegexp = regexp.MustCompile(`\ABOX\((\d+(?:\.\d*)?) (\d+(?:\.\d*)?),(\d+(?:\.\d*)?) (\d+(?:\.\d*)?)\)\z`)
-
 // A box2DCodec implements [github.com/jackc/pgx/v5/pgtype.Codec] for
-// [github.com/twpayne/go-geos.Box2D] types.
-type box2DCodec struct{}
+// [github.com/twpayne/go-geos.Box2D] types. Values can also be scanned into
+// and encoded from [github.com/twpayne/go-geos.Box3D]s, with the Z bounds minZ
+// and maxZ, and geometries, as rectangle polygons and envelopes respectively.
+type box2DCodec struct {
+	geosContexts ContextPool
+	minZ         float64
+	maxZ         float64
+	validation   ValidationPolicy
+	scanHooks    []ScanHook
+}
 
 // A box2DTextEncodePlan implements
 // [github.com/jackc/pgx/v5/pgtype.EncodePlan] for
 // [github.com/twpayne/go-geos.Box2D] types in text format.
-type box2DTextEncodePlan struct{}
+type box2DTextEncodePlan struct {
+	codec *box2DCodec
+}
 
 // A box2DTextScanPlan implements [github.com/jackc/pgx/v5/pgtype.ScanPlan]
 // for [github.com/twpayne/go-geos.Box2D] types in text format.
-type box2DTextScanPlan struct{}
+type box2DTextScanPlan struct {
+	codec *box2DCodec
+}
 
 // FormatSupported implements
 // [github.com/jackc/pgx/v5/pgtype.Codec.FormatSupported].
@@ -49,13 +55,17 @@ func (c *box2DCodec) PreferredFormat() int16 {
 // PlanEncode implements [github.com/jackc/pgx/v5/pgtype.Codec.PlanEncode].
 func (c *box2DCodec) PlanEncode(m *pgtype.Map, old uint32, format int16, value any) pgtype.EncodePlan {
 	switch value.(type) {
-	case geos.Box2D, *geos.Box2D:
-		switch format {
-		case pgtype.TextFormatCode:
-			return box2DTextEncodePlan{}
-		default:
+	case geos.Box2D, *geos.Box2D, Box2D, *Box2D, geos.Box3D, *geos.Box3D, Box3D, *Box3D:
+	default:
+		if _, ok := geomFromValue(value); !ok {
 			return nil
 		}
+	}
+	switch format {
+	case pgtype.TextFormatCode:
+		return box2DTextEncodePlan{
+			codec: c,
+		}
 	default:
 		return nil
 	}
@@ -63,12 +73,16 @@ func (c *box2DCodec) PlanEncode(m *pgtype.Map, old uint32, format int16, value a
 
 // PlanScan implements [github.com/jackc/pgx/v5/pgtype.Codec.PlanScan].
 func (c *box2DCodec) PlanScan(m *pgtype.Map, old uint32, format int16, target any) pgtype.ScanPlan {
-	if _, ok := target.(*geos.Box2D); !ok {
+	switch target.(type) {
+	case *geos.Box2D, *Box2D, *geos.Box3D, *Box3D, **geos.Geom, *Geom, *NullGeom:
+	default:
 		return nil
 	}
 	switch format {
 	case pgx.TextFormatCode:
-		return box2DTextScanPlan{}
+		return box2DTextScanPlan{
+			codec: c,
+		}
 	default:
 		return nil
 	}
@@ -76,8 +90,18 @@ func (c *box2DCodec) PlanScan(m *pgtype.Map, old uint32, format int16, target an
 
 // DecodeDatabaseSQLValue implements
 // [github.com/jackc/pgx/v5/pgtype.Codec.DecodeDatabaseSQLValue].
+//
+// It returns the box in text format as a string.
 func (c *box2DCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
-	return nil, errors.ErrUnsupported
+	if src == nil {
+		return nil, nil
+	}
+	switch format {
+	case pgtype.TextFormatCode:
+		return string(src), nil
+	default:
+		return nil, errors.ErrUnsupported
+	}
 }
 
 // DecodeValue implements [github.com/jackc/pgx/v5/pgtype.Codec.DecodeValue].
@@ -85,7 +109,7 @@ func (c *box2DCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []
 	switch format {
 	case pgtype.TextFormatCode:
 		var box2D geos.Box2D
-		if err := decodeBox2D(&box2D, src); err != nil {
+		if err := c.decode(&box2D, src); err != nil {
 			return nil, err
 		}
 		return &box2D, nil
@@ -98,62 +122,124 @@ func (c *box2DCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []
 func (p box2DTextEncodePlan) Encode(value any, buf []byte) (newBuf []byte, err error) {
 	switch box2D := value.(type) {
 	case geos.Box2D:
-		return encodeBox2D(&box2D)
+		return p.codec.encode(buf, &box2D)
 	case *geos.Box2D:
-		return encodeBox2D(box2D)
+		return p.codec.encode(buf, box2D)
+	case Box2D:
+		return p.codec.encode(buf, &box2D.Box2D)
+	case *Box2D:
+		return p.codec.encode(buf, &box2D.Box2D)
+	case geos.Box3D:
+		return p.codec.encode(buf, box2DFromBox3D(&box2D))
+	case *geos.Box3D:
+		return p.codec.encode(buf, box2DFromBox3D(box2D))
+	case Box3D:
+		return p.codec.encode(buf, box2DFromBox3D(&box2D.Box3D))
+	case *Box3D:
+		return p.codec.encode(buf, box2DFromBox3D(&box2D.Box3D))
 	default:
-		return nil, errors.ErrUnsupported
+		geom, ok := geomFromValue(value)
+		if !ok {
+			return nil, errors.ErrUnsupported
+		}
+		envelope, err := geomEnvelope(geom)
+		if err != nil || envelope == nil {
+			return nil, err
+		}
+		return p.codec.encode(buf, box2DFromBox3D(envelope))
 	}
 }
 
 // Scan implements [github.com/jackc/pgx/v5/pgtype.ScanPlan.Scan].
 func (p box2DTextScanPlan) Scan(src []byte, target any) error {
-	box2D, ok := target.(*geos.Box2D)
-	if !ok {
-		return errors.ErrUnsupported
+	switch box2D := target.(type) {
+	case *geos.Box2D:
+		return p.codec.decode(box2D, src)
+	case *Box2D:
+		return p.codec.decode(&box2D.Box2D, src)
 	}
-	return decodeBox2D(box2D, src)
-}
-
-func decodeBox2D(box2D *geos.Box2D, src []byte) error {
-	m := box2DRegexp.FindSubmatch(src)
-	if m == nil {
-		return fmt.Errorf("%q: invalid BOX2D", string(src))
+	if src == nil {
+		switch target.(type) {
+		case **geos.Geom, *Geom, *NullGeom:
+			return scanGeom(target, nil)
+		}
+	}
+	var box2D geos.Box2D
+	if err := p.codec.decode(&box2D, src); err != nil {
+		return err
+	}
+	switch target := target.(type) {
+	case *geos.Box3D:
+		*target = *box3DFromBox2D(&box2D, p.codec.minZ, p.codec.maxZ)
+		return nil
+	case *Box3D:
+		target.Box3D = *box3DFromBox2D(&box2D, p.codec.minZ, p.codec.maxZ)
+		return nil
+	default:
+		geom, err := p.codec.geosContexts.Context().NewGeomFromWKB(box2DToEWKB(&box2D))
+		if err != nil {
+			return err
+		}
+		return scanGeom(target, geom)
 	}
-	box2D.MinX, _ = strconv.ParseFloat(string(m[1]), 64)
-	box2D.MinY, _ = strconv.ParseFloat(string(m[2]), 64)
-	box2D.MaxX, _ = strconv.ParseFloat(string(m[3]), 64)
-	box2D.MaxY, _ = strconv.ParseFloat(string(m[4]), 64)
-	return nil
 }
 
-func encodeBox2D(box2D *geos.Box2D) ([]byte, error) {
-	var builder strings.Builder
-	builder.Grow(64)
-	builder.WriteString("BOX(")
-	builder.WriteString(strconv.FormatFloat(box2D.MinX, 'f', -1, 64))
-	builder.WriteByte(' ')
-	builder.WriteString(strconv.FormatFloat(box2D.MinY, 'f', -1, 64))
-	builder.WriteByte(',')
-	builder.WriteString(strconv.FormatFloat(box2D.MaxX, 'f', -1, 64))
-	builder.WriteByte(' ')
-	builder.WriteString(strconv.FormatFloat(box2D.MaxY, 'f', -1, 64))
-	builder.WriteByte(')')
-	return []byte(builder.String()), nil
-}
-
-// registerBox2D registers codecs for [github.com/twpayne/go-geos.Box2D] types on conn.
-func registerBox2D(ctx context.Context, conn *pgx.Conn) error {
-	var box2dOID uint32
-	if err := conn.QueryRow(ctx, "select 'box2d'::text::regtype::oid").Scan(&box2dOID); err != nil {
+// decode decodes box2D from src, with c's validation policy and scan hooks
+// applied.
+func (c *box2DCodec) decode(box2D *geos.Box2D, src []byte) error {
+	if err := decodeBox2D(box2D, src); err != nil {
+		return err
+	}
+	if err := validateBox2D(c.validation, box2D); err != nil {
 		return err
 	}
+	return runScanHooks(c.scanHooks, box2D)
+}
 
-	conn.TypeMap().RegisterType(&pgtype.Type{
-		Codec: &box2DCodec{},
-		Name:  "box2d",
-		OID:   box2dOID,
-	})
+// encode appends box2D to buf, with c's validation policy applied.
+func (c *box2DCodec) encode(buf []byte, box2D *geos.Box2D) ([]byte, error) {
+	if c.validation != ValidationNone {
+		validBox2D := *box2D
+		if err := validateBox2D(c.validation, &validBox2D); err != nil {
+			return nil, err
+		}
+		box2D = &validBox2D
+	}
+	return appendBox2D(buf, box2D), nil
+}
 
+// validateBox2D applies policy to box2D, which may be modified in place.
+func validateBox2D(policy ValidationPolicy, box2D *geos.Box2D) error {
+	switch policy {
+	case ValidationNone:
+	case ValidationReject:
+		if box2D.MinX > box2D.MaxX || box2D.MinY > box2D.MaxY {
+			return fmt.Errorf("%s: %w", box2D, ErrInvalidGeometry)
+		}
+	case ValidationMakeValid:
+		if box2D.MinX > box2D.MaxX {
+			box2D.MinX, box2D.MaxX = box2D.MaxX, box2D.MinX
+		}
+		if box2D.MinY > box2D.MaxY {
+			box2D.MinY, box2D.MaxY = box2D.MaxY, box2D.MinY
+		}
+	}
 	return nil
 }
+
+// NewBox2DCodec returns a new codec for box2d values that uses opts. It can be
+// registered on any [github.com/jackc/pgx/v5/pgtype.Map].
+func NewBox2DCodec(opts ...Option) pgtype.Codec {
+	return newBox2DCodec(newOptions(opts).forConnection())
+}
+
+// newBox2DCodec returns a new box2DCodec with options.
+func newBox2DCodec(options *options) *box2DCodec {
+	return &box2DCodec{
+		geosContexts: options.contextPool,
+		minZ:         options.boxMinZ,
+		maxZ:         options.boxMaxZ,
+		validation:   options.validation,
+		scanHooks:    options.scanHooks,
+	}
+}
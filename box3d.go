@@ -1,33 +1,41 @@
 package pgxgeos
 
 import (
-	"context"
 	"database/sql/driver"
 	"errors"
 	"fmt"
-	"regexp"
-	"strconv"
-	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/twpayne/go-geos"
 )
 
-var box3DRegexp = regexp.MustCompile(`\ABOX3D\((\d+(?:\.\d*)?) (\d+(?:\.\d*)?) (\d+(?:\.\d*)?),(\d+(?:\.\d*)?) (\d+(?:\.\d*)?) (\d+(?:\.\d*)?)\)\z`)
-
 // A box3DCodec implements [github.com/jackc/pgx/v5/pgtype.Codec] for
-// [github.com/twpayne/go-geos.Box3D] types.
-type box3DCodec struct{}
+// [github.com/twpayne/go-geos.Box3D] types. Values can also be scanned into
+// [github.com/twpayne/go-geos.Box2D]s, dropping the Z bounds, and encoded from
+// them, with the Z bounds minZ and maxZ. Values can be scanned into
+// geometries, as the faces of the box, and encoded from geometries, as their
+// envelopes.
+type box3DCodec struct {
+	geosContexts ContextPool
+	minZ         float64
+	maxZ         float64
+	validation   ValidationPolicy
+	scanHooks    []ScanHook
+}
 
 // A box3DTextEncodePlan implements
 // [github.com/jackc/pgx/v5/pgtype.EncodePlan] for
 // [github.com/twpayne/go-geos.Box3D] types in text format.
-type box3DTextEncodePlan struct{}
+type box3DTextEncodePlan struct {
+	codec *box3DCodec
+}
 
 // A box3DTextScanPlan implements [github.com/jackc/pgx/v5/pgtype.ScanPlan]
 // for [github.com/twpayne/go-geos.Box3D] types in text format.
-type box3DTextScanPlan struct{}
+type box3DTextScanPlan struct {
+	codec *box3DCodec
+}
 
 // FormatSupported implements
 // [github.com/jackc/pgx/v5/pgtype.Codec.FormatSupported].
@@ -49,13 +57,17 @@ func (c *box3DCodec) PreferredFormat() int16 {
 // PlanEncode implements [github.com/jackc/pgx/v5/pgtype.Codec.PlanEncode].
 func (c *box3DCodec) PlanEncode(m *pgtype.Map, old uint32, format int16, value any) pgtype.EncodePlan {
 	switch value.(type) {
-	case geos.Box3D, *geos.Box3D:
-		switch format {
-		case pgtype.TextFormatCode:
-			return box3DTextEncodePlan{}
-		default:
+	case geos.Box3D, *geos.Box3D, Box3D, *Box3D, geos.Box2D, *geos.Box2D, Box2D, *Box2D:
+	default:
+		if _, ok := geomFromValue(value); !ok {
 			return nil
 		}
+	}
+	switch format {
+	case pgtype.TextFormatCode:
+		return box3DTextEncodePlan{
+			codec: c,
+		}
 	default:
 		return nil
 	}
@@ -63,12 +75,16 @@ func (c *box3DCodec) PlanEncode(m *pgtype.Map, old uint32, format int16, value a
 
 // PlanScan implements [github.com/jackc/pgx/v5/pgtype.Codec.PlanScan].
 func (c *box3DCodec) PlanScan(m *pgtype.Map, old uint32, format int16, target any) pgtype.ScanPlan {
-	if _, ok := target.(*geos.Box3D); !ok {
+	switch target.(type) {
+	case *geos.Box3D, *Box3D, *geos.Box2D, *Box2D, **geos.Geom, *Geom, *NullGeom:
+	default:
 		return nil
 	}
 	switch format {
 	case pgx.TextFormatCode:
-		return box3DTextScanPlan{}
+		return box3DTextScanPlan{
+			codec: c,
+		}
 	default:
 		return nil
 	}
@@ -76,8 +92,18 @@ func (c *box3DCodec) PlanScan(m *pgtype.Map, old uint32, format int16, target an
 
 // DecodeDatabaseSQLValue implements
 // [github.com/jackc/pgx/v5/pgtype.Codec.DecodeDatabaseSQLValue].
+//
+// It returns the box in text format as a string.
 func (c *box3DCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
-	return nil, errors.ErrUnsupported
+	if src == nil {
+		return nil, nil
+	}
+	switch format {
+	case pgtype.TextFormatCode:
+		return string(src), nil
+	default:
+		return nil, errors.ErrUnsupported
+	}
 }
 
 // DecodeValue implements [github.com/jackc/pgx/v5/pgtype.Codec.DecodeValue].
@@ -85,7 +111,7 @@ func (c *box3DCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []
 	switch format {
 	case pgtype.TextFormatCode:
 		var box3D geos.Box3D
-		if err := decodeBox3D(&box3D, src); err != nil {
+		if err := c.decode(&box3D, src); err != nil {
 			return nil, err
 		}
 		return &box3D, nil
@@ -98,68 +124,127 @@ func (c *box3DCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []
 func (p box3DTextEncodePlan) Encode(value any, buf []byte) (newBuf []byte, err error) {
 	switch box3D := value.(type) {
 	case geos.Box3D:
-		return encodeBox3D(&box3D)
+		return p.codec.encode(buf, &box3D)
 	case *geos.Box3D:
-		return encodeBox3D(box3D)
+		return p.codec.encode(buf, box3D)
+	case Box3D:
+		return p.codec.encode(buf, &box3D.Box3D)
+	case *Box3D:
+		return p.codec.encode(buf, &box3D.Box3D)
+	case geos.Box2D:
+		return p.codec.encode(buf, box3DFromBox2D(&box3D, p.codec.minZ, p.codec.maxZ))
+	case *geos.Box2D:
+		return p.codec.encode(buf, box3DFromBox2D(box3D, p.codec.minZ, p.codec.maxZ))
+	case Box2D:
+		return p.codec.encode(buf, box3DFromBox2D(&box3D.Box2D, p.codec.minZ, p.codec.maxZ))
+	case *Box2D:
+		return p.codec.encode(buf, box3DFromBox2D(&box3D.Box2D, p.codec.minZ, p.codec.maxZ))
 	default:
-		return nil, errors.ErrUnsupported
+		geom, ok := geomFromValue(value)
+		if !ok {
+			return nil, errors.ErrUnsupported
+		}
+		envelope, err := geomEnvelope(geom)
+		if err != nil || envelope == nil {
+			return nil, err
+		}
+		return p.codec.encode(buf, envelope)
 	}
 }
 
 // Scan implements [github.com/jackc/pgx/v5/pgtype.ScanPlan.Scan].
 func (p box3DTextScanPlan) Scan(src []byte, target any) error {
-	box3D, ok := target.(*geos.Box3D)
-	if !ok {
-		return errors.ErrUnsupported
+	switch box3D := target.(type) {
+	case *geos.Box3D:
+		return p.codec.decode(box3D, src)
+	case *Box3D:
+		return p.codec.decode(&box3D.Box3D, src)
 	}
-	return decodeBox3D(box3D, src)
-}
-
-func decodeBox3D(box3D *geos.Box3D, src []byte) error {
-	m := box3DRegexp.FindSubmatch(src)
-	if m == nil {
-		return fmt.Errorf("%q: invalid BOX3D", string(src))
+	if src == nil {
+		switch target.(type) {
+		case **geos.Geom, *Geom, *NullGeom:
+			return scanGeom(target, nil)
+		}
+	}
+	var box3D geos.Box3D
+	if err := p.codec.decode(&box3D, src); err != nil {
+		return err
+	}
+	switch target := target.(type) {
+	case *geos.Box2D:
+		*target = *box2DFromBox3D(&box3D)
+		return nil
+	case *Box2D:
+		target.Box2D = *box2DFromBox3D(&box3D)
+		return nil
+	default:
+		geom, err := p.codec.geosContexts.Context().NewGeomFromWKB(box3DToEWKB(&box3D))
+		if err != nil {
+			return err
+		}
+		return scanGeom(target, geom)
 	}
-	box3D.MinX, _ = strconv.ParseFloat(string(m[1]), 64)
-	box3D.MinY, _ = strconv.ParseFloat(string(m[2]), 64)
-	box3D.MinZ, _ = strconv.ParseFloat(string(m[3]), 64)
-	box3D.MaxX, _ = strconv.ParseFloat(string(m[4]), 64)
-	box3D.MaxY, _ = strconv.ParseFloat(string(m[5]), 64)
-	box3D.MaxZ, _ = strconv.ParseFloat(string(m[6]), 64)
-	return nil
 }
 
-func encodeBox3D(box3D *geos.Box3D) ([]byte, error) {
-	var builder strings.Builder
-	builder.Grow(128)
-	builder.WriteString("BOX3D(")
-	builder.WriteString(strconv.FormatFloat(box3D.MinX, 'f', -1, 64))
-	builder.WriteByte(' ')
-	builder.WriteString(strconv.FormatFloat(box3D.MinY, 'f', -1, 64))
-	builder.WriteByte(' ')
-	builder.WriteString(strconv.FormatFloat(box3D.MinZ, 'f', -1, 64))
-	builder.WriteByte(',')
-	builder.WriteString(strconv.FormatFloat(box3D.MaxX, 'f', -1, 64))
-	builder.WriteByte(' ')
-	builder.WriteString(strconv.FormatFloat(box3D.MaxY, 'f', -1, 64))
-	builder.WriteByte(' ')
-	builder.WriteString(strconv.FormatFloat(box3D.MaxZ, 'f', -1, 64))
-	builder.WriteByte(')')
-	return []byte(builder.String()), nil
-}
-
-// registerBox3D registers codecs for [github.com/twpayne/go-geos.Box3D] types on conn.
-func registerBox3D(ctx context.Context, conn *pgx.Conn) error {
-	var box3dOID uint32
-	if err := conn.QueryRow(ctx, "select 'box3d'::text::regtype::oid").Scan(&box3dOID); err != nil {
+// decode decodes box3D from src, with c's validation policy and scan hooks
+// applied.
+func (c *box3DCodec) decode(box3D *geos.Box3D, src []byte) error {
+	if err := decodeBox3D(box3D, src); err != nil {
+		return err
+	}
+	if err := validateBox3D(c.validation, box3D); err != nil {
 		return err
 	}
+	return runScanHooks(c.scanHooks, box3D)
+}
 
-	conn.TypeMap().RegisterType(&pgtype.Type{
-		Codec: &box3DCodec{},
-		Name:  "box3d",
-		OID:   box3dOID,
-	})
+// encode appends box3D to buf, with c's validation policy applied.
+func (c *box3DCodec) encode(buf []byte, box3D *geos.Box3D) ([]byte, error) {
+	if c.validation != ValidationNone {
+		validBox3D := *box3D
+		if err := validateBox3D(c.validation, &validBox3D); err != nil {
+			return nil, err
+		}
+		box3D = &validBox3D
+	}
+	return appendBox3D(buf, box3D), nil
+}
 
+// validateBox3D applies policy to box3D, which may be modified in place.
+func validateBox3D(policy ValidationPolicy, box3D *geos.Box3D) error {
+	switch policy {
+	case ValidationNone:
+	case ValidationReject:
+		if box3D.MinX > box3D.MaxX || box3D.MinY > box3D.MaxY || box3D.MinZ > box3D.MaxZ {
+			return fmt.Errorf("%s: %w", box3D, ErrInvalidGeometry)
+		}
+	case ValidationMakeValid:
+		if box3D.MinX > box3D.MaxX {
+			box3D.MinX, box3D.MaxX = box3D.MaxX, box3D.MinX
+		}
+		if box3D.MinY > box3D.MaxY {
+			box3D.MinY, box3D.MaxY = box3D.MaxY, box3D.MinY
+		}
+		if box3D.MinZ > box3D.MaxZ {
+			box3D.MinZ, box3D.MaxZ = box3D.MaxZ, box3D.MinZ
+		}
+	}
 	return nil
 }
+
+// NewBox3DCodec returns a new codec for box3d values that uses opts. It can be
+// registered on any [github.com/jackc/pgx/v5/pgtype.Map].
+func NewBox3DCodec(opts ...Option) pgtype.Codec {
+	return newBox3DCodec(newOptions(opts).forConnection())
+}
+
+// newBox3DCodec returns a new box3DCodec with options.
+func newBox3DCodec(options *options) *box3DCodec {
+	return &box3DCodec{
+		geosContexts: options.contextPool,
+		minZ:         options.boxMinZ,
+		maxZ:         options.boxMaxZ,
+		validation:   options.validation,
+		scanHooks:    options.scanHooks,
+	}
+}
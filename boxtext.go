@@ -0,0 +1,202 @@
+package pgxgeos
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/twpayne/go-geos"
+)
+
+// A BoxSyntaxError is returned when a box2d or box3d value in text format
+// cannot be parsed.
+type BoxSyntaxError struct {
+	Type   string // BOX or BOX3D.
+	Text   string // The text that could not be parsed.
+	Offset int    // The byte offset in Text at which parsing failed.
+	Msg    string // A description of the failure.
+}
+
+// Error implements [error.Error].
+func (e *BoxSyntaxError) Error() string {
+	return fmt.Sprintf("%q: offset %d: %s: invalid %s", e.Text, e.Offset, e.Msg, e.Type)
+}
+
+// A boxParser parses box2d and box3d values in text format, as output by
+// PostGIS, for example BOX(-1.5 2e-07,inf nan). It does not allocate unless
+// parsing fails.
+type boxParser struct {
+	typ string
+	src []byte
+	pos int
+}
+
+// parseBox parses the box in src, which must start with typ, into coords.
+func parseBox(typ string, src []byte, coords []float64) error {
+	p := boxParser{
+		typ: typ,
+		src: src,
+	}
+	p.skipSpace()
+	if err := p.expectPrefix(typ); err != nil {
+		return err
+	}
+	p.skipSpace()
+	if err := p.expectByte('('); err != nil {
+		return err
+	}
+	corner := len(coords) / 2
+	for i := range coords {
+		switch {
+		case i == corner:
+			p.skipSpace()
+			if err := p.expectByte(','); err != nil {
+				return err
+			}
+			p.skipSpace()
+		case i > 0:
+			if !p.skipSpace() {
+				return p.errorf("expected space")
+			}
+		default:
+			p.skipSpace()
+		}
+		var err error
+		if coords[i], err = p.float(); err != nil {
+			return err
+		}
+	}
+	p.skipSpace()
+	if err := p.expectByte(')'); err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.pos != len(p.src) {
+		return p.errorf("unexpected trailing data")
+	}
+	return nil
+}
+
+// errorf returns a new *BoxSyntaxError at p's current offset.
+func (p *boxParser) errorf(format string, args ...any) error {
+	return &BoxSyntaxError{
+		Type:   p.typ,
+		Text:   string(p.src),
+		Offset: p.pos,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// expectByte consumes c or returns an error.
+func (p *boxParser) expectByte(c byte) error {
+	if p.pos >= len(p.src) || p.src[p.pos] != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// expectPrefix consumes prefix, ignoring case, or returns an error.
+func (p *boxParser) expectPrefix(prefix string) error {
+	if len(p.src)-p.pos < len(prefix) {
+		return p.errorf("expected %s", prefix)
+	}
+	for i := range len(prefix) {
+		if c := p.src[p.pos+i]; c != prefix[i] && c|0x20 != prefix[i]|0x20 {
+			return p.errorf("expected %s", prefix)
+		}
+	}
+	p.pos += len(prefix)
+	return nil
+}
+
+// float consumes a floating point number, including exponents, infinities,
+// and NaNs.
+func (p *boxParser) float() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.src) && !isBoxDelimiter(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, p.errorf("expected number")
+	}
+	token := p.src[start:p.pos]
+	value, err := strconv.ParseFloat(string(token), 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		p.pos = start
+		return 0, p.errorf("invalid number %q", token)
+	}
+	return value, nil
+}
+
+// skipSpace consumes any whitespace and returns whether any was consumed.
+func (p *boxParser) skipSpace() bool {
+	start := p.pos
+	for p.pos < len(p.src) && isBoxSpace(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// isBoxDelimiter returns whether c ends a number.
+func isBoxDelimiter(c byte) bool {
+	return c == ',' || c == '(' || c == ')' || isBoxSpace(c)
+}
+
+// isBoxSpace returns whether c is whitespace.
+func isBoxSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// appendBoxFloat appends value to buf in a format that parseBox and PostGIS
+// both parse back to exactly value.
+func appendBoxFloat(buf []byte, value float64) []byte {
+	return strconv.AppendFloat(buf, value, 'g', -1, 64)
+}
+
+func decodeBox2D(box2D *geos.Box2D, src []byte) error {
+	var coords [4]float64
+	if err := parseBox("BOX", src, coords[:]); err != nil {
+		return err
+	}
+	box2D.MinX, box2D.MinY, box2D.MaxX, box2D.MaxY = coords[0], coords[1], coords[2], coords[3]
+	return nil
+}
+
+func appendBox2D(buf []byte, box2D *geos.Box2D) []byte {
+	buf = append(buf, "BOX("...)
+	buf = appendBoxFloat(buf, box2D.MinX)
+	buf = append(buf, ' ')
+	buf = appendBoxFloat(buf, box2D.MinY)
+	buf = append(buf, ',')
+	buf = appendBoxFloat(buf, box2D.MaxX)
+	buf = append(buf, ' ')
+	buf = appendBoxFloat(buf, box2D.MaxY)
+	return append(buf, ')')
+}
+
+func decodeBox3D(box3D *geos.Box3D, src []byte) error {
+	var coords [6]float64
+	if err := parseBox("BOX3D", src, coords[:]); err != nil {
+		return err
+	}
+	box3D.MinX, box3D.MinY, box3D.MinZ = coords[0], coords[1], coords[2]
+	box3D.MaxX, box3D.MaxY, box3D.MaxZ = coords[3], coords[4], coords[5]
+	return nil
+}
+
+func appendBox3D(buf []byte, box3D *geos.Box3D) []byte {
+	buf = append(buf, "BOX3D("...)
+	buf = appendBoxFloat(buf, box3D.MinX)
+	buf = append(buf, ' ')
+	buf = appendBoxFloat(buf, box3D.MinY)
+	buf = append(buf, ' ')
+	buf = appendBoxFloat(buf, box3D.MinZ)
+	buf = append(buf, ',')
+	buf = appendBoxFloat(buf, box3D.MaxX)
+	buf = append(buf, ' ')
+	buf = appendBoxFloat(buf, box3D.MaxY)
+	buf = append(buf, ' ')
+	buf = appendBoxFloat(buf, box3D.MaxZ)
+	return append(buf, ')')
+}
@@ -0,0 +1,248 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+const (
+	box2DTestOID = 100001
+	box3DTestOID = 100002
+)
+
+func newBoxTestMap() *pgtype.Map {
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Name: "box2d", OID: box2DTestOID, Codec: pgxgeos.NewBox2DCodec()})
+	m.RegisterType(&pgtype.Type{Name: "box3d", OID: box3DTestOID, Codec: pgxgeos.NewBox3DCodec()})
+	return m
+}
+
+func TestBoxText(t *testing.T) {
+	m := newBoxTestMap()
+	for _, tc := range []struct {
+		text          string
+		expectedBox2D *geos.Box2D
+		expectedBox3D *geos.Box3D
+	}{
+		{
+			text:          "BOX(1 2,3 4)",
+			expectedBox2D: geos.NewBox2D(1, 2, 3, 4),
+		},
+		{
+			text:          "BOX(-122.4194 -37.8136,-0.5 1e-07)",
+			expectedBox2D: geos.NewBox2D(-122.4194, -37.8136, -0.5, 1e-07),
+		},
+		{
+			text:          "box ( 1.5E+300\t-2 , +3 4 ) ",
+			expectedBox2D: geos.NewBox2D(1.5e300, -2, 3, 4),
+		},
+		{
+			text:          "BOX(-inf -Infinity,inf +Inf)",
+			expectedBox2D: geos.NewBox2D(math.Inf(-1), math.Inf(-1), math.Inf(1), math.Inf(1)),
+		},
+		{
+			text:          "BOX3D(-1 -2 -3.25,4e2 5 6)",
+			expectedBox3D: geos.NewBox3D(-1, -2, -3.25, 400, 5, 6),
+		},
+		{
+			text:          "BOX3D(inf -inf 0,1 2 3)",
+			expectedBox3D: geos.NewBox3D(math.Inf(1), math.Inf(-1), 0, 1, 2, 3),
+		},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			if tc.expectedBox2D != nil {
+				var actual geos.Box2D
+				assert.NoError(t, m.Scan(box2DTestOID, pgx.TextFormatCode, []byte(tc.text), &actual))
+				assert.Equal(t, *tc.expectedBox2D, actual)
+			}
+			if tc.expectedBox3D != nil {
+				var actual geos.Box3D
+				assert.NoError(t, m.Scan(box3DTestOID, pgx.TextFormatCode, []byte(tc.text), &actual))
+				assert.Equal(t, *tc.expectedBox3D, actual)
+			}
+		})
+	}
+}
+
+func TestBoxTextNaN(t *testing.T) {
+	m := newBoxTestMap()
+	var actual geos.Box2D
+	assert.NoError(t, m.Scan(box2DTestOID, pgx.TextFormatCode, []byte("BOX(nan 1,NaN 2)"), &actual))
+	assert.True(t, math.IsNaN(actual.MinX))
+	assert.Equal(t, 1, actual.MinY)
+	assert.True(t, math.IsNaN(actual.MaxX))
+	assert.Equal(t, 2, actual.MaxY)
+}
+
+func TestBoxTextError(t *testing.T) {
+	m := newBoxTestMap()
+	for _, tc := range []struct {
+		oid            uint32
+		text           string
+		expectedOffset int
+	}{
+		{oid: box2DTestOID, text: "", expectedOffset: 0},
+		{oid: box2DTestOID, text: "BOX3D(1 2 3,4 5 6)", expectedOffset: 3},
+		{oid: box2DTestOID, text: "BOX(1 2,3)", expectedOffset: 9},
+		{oid: box2DTestOID, text: "BOX(1 2 3,4 5)", expectedOffset: 8},
+		{oid: box2DTestOID, text: "BOX(1 x,3 4)", expectedOffset: 6},
+		{oid: box2DTestOID, text: "BOX(1 2,3 4) x", expectedOffset: 13},
+		{oid: box2DTestOID, text: "BOX(1 2,3 4", expectedOffset: 11},
+		{oid: box3DTestOID, text: "BOX(1 2,3 4)", expectedOffset: 0},
+		{oid: box3DTestOID, text: "BOX3D(1 2 3,4 5 --6)", expectedOffset: 16},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			var err error
+			switch tc.oid {
+			case box2DTestOID:
+				var box2D geos.Box2D
+				err = m.Scan(tc.oid, pgx.TextFormatCode, []byte(tc.text), &box2D)
+			case box3DTestOID:
+				var box3D geos.Box3D
+				err = m.Scan(tc.oid, pgx.TextFormatCode, []byte(tc.text), &box3D)
+			}
+			var boxSyntaxError *pgxgeos.BoxSyntaxError
+			assert.True(t, errors.As(err, &boxSyntaxError))
+			assert.Equal(t, tc.text, boxSyntaxError.Text)
+			assert.Equal(t, tc.expectedOffset, boxSyntaxError.Offset)
+		})
+	}
+}
+
+func TestBoxTextAllocs(t *testing.T) {
+	m := newBoxTestMap()
+	src := []byte("BOX3D(-122.4194 -37.8136 -1e-07,-0.5 1.5e+300 inf)")
+	var box3D geos.Box3D
+	assert.NoError(t, m.Scan(box3DTestOID, pgx.TextFormatCode, src, &box3D))
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = m.Scan(box3DTestOID, pgx.TextFormatCode, src, &box3D)
+	})
+	assert.Equal(t, 0, allocs)
+}
+
+func TestBox2DCodecNegative(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		box2D := geos.NewBox2D(-122.4194, -37.8136, -0.5, 1e-07)
+		var actual geos.Box2D
+		assert.NoError(tb, conn.QueryRow(ctx, "select $1::box2d", box2D).Scan(&actual))
+		assert.Equal(tb, *box2D, actual)
+	})
+}
+
+func TestBox3DCodecNegative(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		box3D := geos.NewBox3D(-122.4194, -37.8136, -1e-07, -0.5, 1.5e+300, 6)
+		var actual geos.Box3D
+		assert.NoError(tb, conn.QueryRow(ctx, "select $1::box3d", box3D).Scan(&actual))
+		assert.Equal(tb, *box3D, actual)
+	})
+}
+
+func FuzzBox2DRoundTrip(f *testing.F) {
+	f.Add(1.0, 2.0, 3.0, 4.0)
+	f.Add(-122.4194, -37.8136, -0.5, 1e-07)
+	f.Add(math.Inf(-1), math.Copysign(0, -1), math.MaxFloat64, math.SmallestNonzeroFloat64)
+	f.Fuzz(func(t *testing.T, minX, minY, maxX, maxY float64) {
+		m := newBoxTestMap()
+		box2D := geos.Box2D{MinX: minX, MinY: minY, MaxX: maxX, MaxY: maxY}
+		text, err := m.Encode(box2DTestOID, pgx.TextFormatCode, box2D, nil)
+		assert.NoError(t, err)
+		var actual geos.Box2D
+		assert.NoError(t, m.Scan(box2DTestOID, pgx.TextFormatCode, text, &actual))
+		assertSameFloats(t,
+			[]float64{box2D.MinX, box2D.MinY, box2D.MaxX, box2D.MaxY},
+			[]float64{actual.MinX, actual.MinY, actual.MaxX, actual.MaxY},
+		)
+	})
+}
+
+func FuzzBox3DRoundTrip(f *testing.F) {
+	f.Add(1.0, 2.0, 3.0, 4.0, 5.0, 6.0)
+	f.Add(-122.4194, -37.8136, -1e-07, -0.5, 1.5e+300, 6.0)
+	f.Add(math.Inf(-1), math.Copysign(0, -1), math.NaN(), math.MaxFloat64, math.SmallestNonzeroFloat64, math.Inf(1))
+	f.Fuzz(func(t *testing.T, minX, minY, minZ, maxX, maxY, maxZ float64) {
+		m := newBoxTestMap()
+		box3D := geos.Box3D{MinX: minX, MinY: minY, MinZ: minZ, MaxX: maxX, MaxY: maxY, MaxZ: maxZ}
+		text, err := m.Encode(box3DTestOID, pgx.TextFormatCode, box3D, nil)
+		assert.NoError(t, err)
+		var actual geos.Box3D
+		assert.NoError(t, m.Scan(box3DTestOID, pgx.TextFormatCode, text, &actual))
+		assertSameFloats(t,
+			[]float64{box3D.MinX, box3D.MinY, box3D.MinZ, box3D.MaxX, box3D.MaxY, box3D.MaxZ},
+			[]float64{actual.MinX, actual.MinY, actual.MinZ, actual.MaxX, actual.MaxY, actual.MaxZ},
+		)
+	})
+}
+
+func FuzzBox2DText(f *testing.F) {
+	f.Add("BOX(1 2,3 4)")
+	f.Add("BOX(-1.5e-07 inf,NaN -Infinity)")
+	f.Add("BOX(1 2,3")
+	f.Fuzz(func(t *testing.T, text string) {
+		m := newBoxTestMap()
+		var box2D geos.Box2D
+		if err := m.Scan(box2DTestOID, pgx.TextFormatCode, []byte(text), &box2D); err != nil {
+			var boxSyntaxError *pgxgeos.BoxSyntaxError
+			assert.True(t, errors.As(err, &boxSyntaxError))
+			assert.True(t, boxSyntaxError.Offset >= 0 && boxSyntaxError.Offset <= len(text))
+			return
+		}
+		reencoded, err := m.Encode(box2DTestOID, pgx.TextFormatCode, box2D, nil)
+		assert.NoError(t, err)
+		var actual geos.Box2D
+		assert.NoError(t, m.Scan(box2DTestOID, pgx.TextFormatCode, reencoded, &actual))
+		assertSameFloats(t,
+			[]float64{box2D.MinX, box2D.MinY, box2D.MaxX, box2D.MaxY},
+			[]float64{actual.MinX, actual.MinY, actual.MaxX, actual.MaxY},
+		)
+	})
+}
+
+func FuzzBox3DText(f *testing.F) {
+	f.Add("BOX3D(1 2 3,4 5 6)")
+	f.Add("BOX3D(-1.5e-07 inf 0,NaN -Infinity 1e308)")
+	f.Add("BOX3D(1 2 3,4 5")
+	f.Fuzz(func(t *testing.T, text string) {
+		m := newBoxTestMap()
+		var box3D geos.Box3D
+		if err := m.Scan(box3DTestOID, pgx.TextFormatCode, []byte(text), &box3D); err != nil {
+			var boxSyntaxError *pgxgeos.BoxSyntaxError
+			assert.True(t, errors.As(err, &boxSyntaxError))
+			assert.True(t, boxSyntaxError.Offset >= 0 && boxSyntaxError.Offset <= len(text))
+			return
+		}
+		reencoded, err := m.Encode(box3DTestOID, pgx.TextFormatCode, box3D, nil)
+		assert.NoError(t, err)
+		var actual geos.Box3D
+		assert.NoError(t, m.Scan(box3DTestOID, pgx.TextFormatCode, reencoded, &actual))
+		assertSameFloats(t,
+			[]float64{box3D.MinX, box3D.MinY, box3D.MinZ, box3D.MaxX, box3D.MaxY, box3D.MaxZ},
+			[]float64{actual.MinX, actual.MinY, actual.MinZ, actual.MaxX, actual.MaxY, actual.MaxZ},
+		)
+	})
+}
+
+// assertSameFloats asserts that expected and actual are bitwise identical,
+// treating all NaNs as identical.
+func assertSameFloats(tb testing.TB, expected, actual []float64) {
+	tb.Helper()
+	assert.Equal(tb, len(expected), len(actual))
+	for i := range expected {
+		if math.IsNaN(expected[i]) {
+			assert.True(tb, math.IsNaN(actual[i]), "index %d", i)
+			continue
+		}
+		assert.Equal(tb, math.Float64bits(expected[i]), math.Float64bits(actual[i]), "index %d", i)
+	}
+}
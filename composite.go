@@ -0,0 +1,152 @@
+package pgxgeos
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/twpayne/go-geos"
+)
+
+// A GeometryDump is a PostGIS geometry_dump, as returned by ST_Dump,
+// ST_DumpPoints, and ST_DumpRings. It implements
+// [github.com/jackc/pgx/v5/pgtype.CompositeIndexScanner] and
+// [github.com/jackc/pgx/v5/pgtype.CompositeIndexGetter].
+type GeometryDump struct {
+	Path []int32
+	Geom *geos.Geom
+}
+
+// A ValidDetail is a PostGIS valid_detail, as returned by ST_IsValidDetail. It
+// implements [github.com/jackc/pgx/v5/pgtype.CompositeIndexScanner] and
+// [github.com/jackc/pgx/v5/pgtype.CompositeIndexGetter].
+//
+// Reason is empty if the geometry is valid.
+type ValidDetail struct {
+	Valid    bool
+	Reason   string
+	Location *geos.Geom
+}
+
+// A nullStringScanner scans text into a string, scanning NULL as the empty
+// string.
+type nullStringScanner struct {
+	s *string
+}
+
+// IsNull implements
+// [github.com/jackc/pgx/v5/pgtype.CompositeIndexGetter.IsNull].
+func (d *GeometryDump) IsNull() bool {
+	return d == nil
+}
+
+// Index implements [github.com/jackc/pgx/v5/pgtype.CompositeIndexGetter.Index].
+func (d *GeometryDump) Index(i int) any {
+	switch i {
+	case 0:
+		return d.Path
+	case 1:
+		return d.Geom
+	default:
+		panic(fmt.Sprintf("%d: invalid index", i))
+	}
+}
+
+// ScanNull implements
+// [github.com/jackc/pgx/v5/pgtype.CompositeIndexScanner.ScanNull].
+func (d *GeometryDump) ScanNull() error {
+	return fmt.Errorf("cannot scan NULL into %T", d)
+}
+
+// ScanIndex implements
+// [github.com/jackc/pgx/v5/pgtype.CompositeIndexScanner.ScanIndex].
+func (d *GeometryDump) ScanIndex(i int) any {
+	switch i {
+	case 0:
+		return &d.Path
+	case 1:
+		return &d.Geom
+	default:
+		panic(fmt.Sprintf("%d: invalid index", i))
+	}
+}
+
+// IsNull implements
+// [github.com/jackc/pgx/v5/pgtype.CompositeIndexGetter.IsNull].
+func (d *ValidDetail) IsNull() bool {
+	return d == nil
+}
+
+// Index implements [github.com/jackc/pgx/v5/pgtype.CompositeIndexGetter.Index].
+func (d *ValidDetail) Index(i int) any {
+	switch i {
+	case 0:
+		return d.Valid
+	case 1:
+		return d.Reason
+	case 2:
+		return d.Location
+	default:
+		panic(fmt.Sprintf("%d: invalid index", i))
+	}
+}
+
+// ScanNull implements
+// [github.com/jackc/pgx/v5/pgtype.CompositeIndexScanner.ScanNull].
+func (d *ValidDetail) ScanNull() error {
+	return fmt.Errorf("cannot scan NULL into %T", d)
+}
+
+// ScanIndex implements
+// [github.com/jackc/pgx/v5/pgtype.CompositeIndexScanner.ScanIndex].
+func (d *ValidDetail) ScanIndex(i int) any {
+	switch i {
+	case 0:
+		return &d.Valid
+	case 1:
+		return nullStringScanner{s: &d.Reason}
+	case 2:
+		return &d.Location
+	default:
+		panic(fmt.Sprintf("%d: invalid index", i))
+	}
+}
+
+// ScanText implements [github.com/jackc/pgx/v5/pgtype.TextScanner.ScanText].
+func (s nullStringScanner) ScanText(v pgtype.Text) error {
+	*s.s = v.String
+	return nil
+}
+
+// newGeometryDumpCodec returns a new codec for geometry_dump types, using the
+// geometry type registered on m. It returns nil if any of the field types are
+// not registered.
+func newGeometryDumpCodec(m *pgtype.Map) pgtype.Codec {
+	return newCompositeCodec(m, []string{"path", "geom"}, []string{"_int4", "geometry"})
+}
+
+// newValidDetailCodec returns a new codec for valid_detail types, using the
+// geometry type registered on m. It returns nil if any of the field types are
+// not registered.
+func newValidDetailCodec(m *pgtype.Map) pgtype.Codec {
+	return newCompositeCodec(m, []string{"valid", "reason", "location"}, []string{"bool", "varchar", "geometry"})
+}
+
+// newCompositeCodec returns a new composite codec with fields with the given
+// names and type names. It returns nil if any of the types are not registered
+// on m.
+func newCompositeCodec(m *pgtype.Map, names, typeNames []string) pgtype.Codec {
+	fields := make([]pgtype.CompositeCodecField, 0, len(names))
+	for i, name := range names {
+		fieldType, ok := m.TypeForName(typeNames[i])
+		if !ok {
+			return nil
+		}
+		fields = append(fields, pgtype.CompositeCodecField{
+			Name: name,
+			Type: fieldType,
+		})
+	}
+	return &pgtype.CompositeCodec{
+		Fields: fields,
+	}
+}
@@ -0,0 +1,90 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestGeometryDumpCodec(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, format := range []int16{
+			pgx.BinaryFormatCode,
+			pgx.TextFormatCode,
+		} {
+			t.Run(strconv.Itoa(int(format)), func(t *testing.T) {
+				rows, err := conn.Query(ctx, "select ST_Dump('SRID=4326;MULTIPOINT(1 2,3 4)'::geometry)", pgx.QueryResultFormats{format})
+				assert.NoError(t, err)
+				geometryDumps, err := pgx.CollectRows(rows, pgx.RowTo[pgxgeos.GeometryDump])
+				assert.NoError(t, err)
+				assert.Equal(t, 2, len(geometryDumps))
+				for i, wkt := range []string{"POINT(1 2)", "POINT(3 4)"} {
+					assert.Equal(t, []int32{int32(i + 1)}, geometryDumps[i].Path)
+					assert.Equal(t, mustNewGeomFromWKT(t, wkt).SetSRID(4326).ToEWKBWithSRID(), geometryDumps[i].Geom.ToEWKBWithSRID())
+				}
+			})
+		}
+	})
+}
+
+func TestGeometryDumpArrayCodec(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		var geometryDumps []pgxgeos.GeometryDump
+		assert.NoError(tb, conn.QueryRow(ctx, "select array_agg(d) from ST_DumpPoints('LINESTRING(1 2,3 4,5 6)'::geometry) as d").Scan(&geometryDumps))
+		assert.Equal(tb, 3, len(geometryDumps))
+		for i, wkt := range []string{"POINT(1 2)", "POINT(3 4)", "POINT(5 6)"} {
+			assert.Equal(tb, []int32{int32(i + 1)}, geometryDumps[i].Path)
+			assert.Equal(tb, mustNewGeomFromWKT(tb, wkt).ToEWKBWithSRID(), geometryDumps[i].Geom.ToEWKBWithSRID())
+		}
+	})
+}
+
+func TestValidDetailCodec(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, format := range []int16{
+			pgx.BinaryFormatCode,
+			pgx.TextFormatCode,
+		} {
+			t.Run(strconv.Itoa(int(format)), func(t *testing.T) {
+				var validDetail pgxgeos.ValidDetail
+				assert.NoError(t, conn.QueryRow(ctx, "select ST_IsValidDetail('POLYGON((0 0,1 1,1 0,0 1,0 0))'::geometry)", pgx.QueryResultFormats{format}).Scan(&validDetail))
+				assert.False(t, validDetail.Valid)
+				assert.Equal(t, "Self-intersection", validDetail.Reason)
+				assert.Equal(t, mustNewGeomFromWKT(t, "POINT(0.5 0.5)").ToEWKBWithSRID(), validDetail.Location.ToEWKBWithSRID())
+
+				assert.NoError(t, conn.QueryRow(ctx, "select ST_IsValidDetail('POINT(1 2)'::geometry)", pgx.QueryResultFormats{format}).Scan(&validDetail))
+				assert.True(t, validDetail.Valid)
+				assert.Equal(t, "", validDetail.Reason)
+				assert.Zero(t, validDetail.Location)
+			})
+		}
+	})
+}
+
+func TestCompositeCodecRequired(t *testing.T) {
+	oids := pgxgeos.OIDs{
+		Geometry:     pgxgeos.TypeOIDs{OID: geometryTestOID},
+		GeometryDump: pgxgeos.TypeOIDs{OID: 100006},
+	}
+
+	report, err := pgxgeos.RegisterTypeMap(pgtype.NewMap(), oids, pgxgeos.WithTypes(pgxgeos.TypeGeometryDump))
+	assert.NoError(t, err)
+	assert.Equal(t, pgxgeos.Type(0), report.Registered)
+
+	_, err = pgxgeos.RegisterTypeMap(pgtype.NewMap(), oids, pgxgeos.WithTypes(pgxgeos.TypeGeometryDump), pgxgeos.WithRequiredTypes(pgxgeos.TypeGeometryDump))
+	assert.IsError(t, err, pgxgeos.ErrTypeNotFound)
+	assert.Contains(t, err.Error(), "geometry_dump")
+
+	report, err = pgxgeos.RegisterTypeMap(pgtype.NewMap(), oids, pgxgeos.WithRequiredTypes(pgxgeos.TypeGeometryDump))
+	assert.NoError(t, err)
+	assert.Equal(t, pgxgeos.TypeGeometry|pgxgeos.TypeGeometryDump, report.Registered)
+}
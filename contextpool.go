@@ -0,0 +1,58 @@
+package pgxgeos
+
+import (
+	"sync/atomic"
+
+	"github.com/twpayne/go-geos"
+)
+
+// A ContextPool provides the GEOS contexts used by codecs to create
+// geometries. go-geos serializes all calls on a context, so spreading work
+// across several contexts allows geometries to be decoded concurrently. Each
+// geometry remains bound to the context that created it.
+//
+// Implementations must be safe for concurrent use.
+type ContextPool interface {
+	Context() *geos.Context
+}
+
+// A roundRobinContextPool is a [ContextPool] that returns its contexts in
+// turn.
+type roundRobinContextPool struct {
+	contexts []*geos.Context
+	next     atomic.Uint64
+}
+
+// A singleContextPool is a [ContextPool] that always returns the same
+// context.
+type singleContextPool struct {
+	geosContext *geos.Context
+}
+
+// NewContextPool returns a new [ContextPool] of size new contexts, which are
+// returned in turn. If size is less than one then the pool has one context.
+func NewContextPool(size int) ContextPool {
+	size = max(size, 1)
+	if size == 1 {
+		return singleContextPool{
+			geosContext: geos.NewContext(),
+		}
+	}
+	contexts := make([]*geos.Context, 0, size)
+	for range size {
+		contexts = append(contexts, geos.NewContext())
+	}
+	return &roundRobinContextPool{
+		contexts: contexts,
+	}
+}
+
+// Context implements [ContextPool.Context].
+func (p *roundRobinContextPool) Context() *geos.Context {
+	return p.contexts[(p.next.Add(1)-1)%uint64(len(p.contexts))]
+}
+
+// Context implements [ContextPool.Context].
+func (p singleContextPool) Context() *geos.Context {
+	return p.geosContext
+}
@@ -0,0 +1,44 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestContextPool(t *testing.T) {
+	pool := pgxgeos.NewContextPool(3)
+	contexts := []*geos.Context{pool.Context(), pool.Context(), pool.Context()}
+	assert.True(t, contexts[0] != contexts[1])
+	assert.True(t, contexts[1] != contexts[2])
+	assert.True(t, contexts[2] != contexts[0])
+	for i := range 6 {
+		assert.True(t, contexts[i%3] == pool.Context())
+	}
+
+	pool = pgxgeos.NewContextPool(0)
+	assert.True(t, pool.Context() == pool.Context())
+}
+
+func TestRegisterWithOptionsContextPool(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, opt := range []pgxgeos.Option{
+			pgxgeos.WithContextPool(pgxgeos.NewContextPool(4)),
+			pgxgeos.WithContextPerConnection(),
+		} {
+			_, err := pgxgeos.RegisterWithOptions(ctx, conn, opt)
+			assert.NoError(tb, err)
+			for range 8 {
+				var geom *geos.Geom
+				assert.NoError(tb, conn.QueryRow(ctx, "select ST_MakePoint(1, 2)").Scan(&geom))
+				assert.True(tb, geom.Equals(mustNewGeomFromWKT(tb, "POINT (1 2)")))
+			}
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestDomainCodec(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		tx, err := conn.Begin(ctx)
+		assert.NoError(tb, err)
+		defer func() {
+			assert.NoError(tb, tx.Rollback(ctx))
+		}()
+
+		_, err = tx.Exec(ctx, `
+			create domain parcel_geom as geometry(MultiPolygon, 27700) check (ST_IsValid(value));
+			create domain small_parcel_geom as parcel_geom check (ST_Area(value) < 1);
+			create domain waypoint_geog as geography(Point, 4326);
+		`)
+		assert.NoError(tb, err)
+
+		report, err := pgxgeos.RegisterWithOptions(ctx, conn)
+		assert.NoError(tb, err)
+		for _, domain := range []string{"parcel_geom", "small_parcel_geom", "waypoint_geog"} {
+			assert.SliceContains(tb, report.Domains, "public."+domain)
+		}
+
+		for _, tc := range []struct {
+			domain string
+			ewkt   string
+			wkt    string
+			srid   int
+		}{
+			{
+				domain: "parcel_geom",
+				ewkt:   "SRID=27700;MULTIPOLYGON(((0 0,2 0,2 2,0 0)))",
+				wkt:    "MULTIPOLYGON(((0 0,2 0,2 2,0 0)))",
+				srid:   27700,
+			},
+			{
+				domain: "small_parcel_geom",
+				ewkt:   "SRID=27700;MULTIPOLYGON(((0 0,1 0,1 1,0 0)))",
+				wkt:    "MULTIPOLYGON(((0 0,1 0,1 1,0 0)))",
+				srid:   27700,
+			},
+			{
+				domain: "waypoint_geog",
+				ewkt:   "SRID=4326;POINT(1 2)",
+				wkt:    "POINT(1 2)",
+				srid:   4326,
+			},
+		} {
+			t.Run(tc.domain, func(t *testing.T) {
+				expected := mustNewGeomFromWKT(t, tc.wkt).SetSRID(tc.srid)
+
+				var actual *geos.Geom
+				assert.NoError(t, tx.QueryRow(ctx, "select $1::text::"+tc.domain, tc.ewkt).Scan(&actual))
+				assert.Equal(t, expected.ToEWKBWithSRID(), actual.ToEWKBWithSRID())
+
+				assert.NoError(t, tx.QueryRow(ctx, "select $1::"+tc.domain, expected).Scan(&actual))
+				assert.Equal(t, expected.ToEWKBWithSRID(), actual.ToEWKBWithSRID())
+
+				var actualArray []*geos.Geom
+				assert.NoError(t, tx.QueryRow(ctx, "select array[$1::text::"+tc.domain+"]", tc.ewkt).Scan(&actualArray))
+				assert.Equal(t, 1, len(actualArray))
+				assert.Equal(t, expected.ToEWKBWithSRID(), actualArray[0].ToEWKBWithSRID())
+			})
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package pgxgeos
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/twpayne/go-geos"
+)
+
+// ewkbEnvelope returns the 3D envelope of ewkb, without using GEOS. The Z
+// bounds are zero if ewkb has no Z ordinates. The envelope of an empty
+// geometry is empty.
+func ewkbEnvelope(ewkb []byte) (*geos.Box3D, error) {
+	envelope := geos.NewBox3DEmpty()
+	hasZ := false
+	rest, err := walkEWKBCoords(ewkb, func(header ewkbHeader, coord []byte) {
+		x := readFloat64(coord, header.byteOrder)
+		y := readFloat64(coord[8:], header.byteOrder)
+		if math.IsNaN(x) || math.IsNaN(y) {
+			return
+		}
+		envelope.MinX = min(envelope.MinX, x)
+		envelope.MinY = min(envelope.MinY, y)
+		envelope.MaxX = max(envelope.MaxX, x)
+		envelope.MaxY = max(envelope.MaxY, y)
+		if header.z {
+			z := readFloat64(coord[16:], header.byteOrder)
+			envelope.MinZ = min(envelope.MinZ, z)
+			envelope.MaxZ = max(envelope.MaxZ, z)
+			hasZ = true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%d trailing bytes: %w", len(rest), ErrInvalidEWKB)
+	}
+	if !hasZ && !math.IsInf(envelope.MinX, 1) {
+		envelope.MinZ, envelope.MaxZ = 0, 0
+	}
+	return envelope, nil
+}
+
+// walkEWKBCoords calls visit with each coordinate of the geometry at the start
+// of ewkb, and returns the remainder of ewkb.
+func walkEWKBCoords(ewkb []byte, visit func(ewkbHeader, []byte)) ([]byte, error) {
+	header, err := parseEWKBHeader(ewkb)
+	if err != nil {
+		return nil, err
+	}
+	ewkb = ewkb[header.size:]
+	var n uint32
+	switch header.geometryType {
+	case GeometryTypePoint:
+		return walkEWKBCoordSeq(ewkb, 1, header, visit)
+	case GeometryTypeLineString, GeometryTypeCircularString:
+		if n, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+			return nil, err
+		}
+		return walkEWKBCoordSeq(ewkb, n, header, visit)
+	case GeometryTypePolygon, GeometryTypeTriangle:
+		if n, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+			return nil, err
+		}
+		for range n {
+			var points uint32
+			if points, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+				return nil, err
+			}
+			if ewkb, err = walkEWKBCoordSeq(ewkb, points, header, visit); err != nil {
+				return nil, err
+			}
+		}
+		return ewkb, nil
+	case GeometryTypeMultiPoint, GeometryTypeMultiLineString, GeometryTypeMultiPolygon, GeometryTypeGeometryCollection,
+		GeometryTypeCompoundCurve, GeometryTypeCurvePolygon, GeometryTypeMultiCurve, GeometryTypeMultiSurface, GeometryTypePolyhedralSurface, GeometryTypeTIN:
+		if n, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+			return nil, err
+		}
+		for range n {
+			if ewkb, err = walkEWKBCoords(ewkb, visit); err != nil {
+				return nil, err
+			}
+		}
+		return ewkb, nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported geometry type: %w", header.geometryType, ErrInvalidEWKB)
+	}
+}
+
+// walkEWKBCoordSeq calls visit with each of the n coordinates at the start of
+// ewkb, which have the ordinates of header, and returns the remainder of ewkb.
+func walkEWKBCoordSeq(ewkb []byte, n uint32, header ewkbHeader, visit func(ewkbHeader, []byte)) ([]byte, error) {
+	size := 8 * header.dimensions()
+	if uint64(len(ewkb)) < uint64(n)*uint64(size) {
+		return nil, fmt.Errorf("short coordinates: %w", ErrInvalidEWKB)
+	}
+	for range n {
+		visit(header, ewkb[:size])
+		ewkb = ewkb[size:]
+	}
+	return ewkb, nil
+}
+
+// box2DToEWKB returns box2D as an EWKB rectangle polygon with no SRID, with
+// the same vertex order as ST_MakeEnvelope. An empty box is an empty polygon.
+func box2DToEWKB(box2D *geos.Box2D) []byte {
+	ewkb := make([]byte, 0, 93)
+	ewkb = append(ewkb, 1)
+	ewkb = binary.LittleEndian.AppendUint32(ewkb, uint32(GeometryTypePolygon))
+	if box2D.IsEmpty() {
+		return binary.LittleEndian.AppendUint32(ewkb, 0)
+	}
+	ewkb = binary.LittleEndian.AppendUint32(ewkb, 1)
+	ewkb = binary.LittleEndian.AppendUint32(ewkb, 5)
+	for _, coord := range [][2]float64{
+		{box2D.MinX, box2D.MinY},
+		{box2D.MinX, box2D.MaxY},
+		{box2D.MaxX, box2D.MaxY},
+		{box2D.MaxX, box2D.MinY},
+		{box2D.MinX, box2D.MinY},
+	} {
+		ewkb = binary.LittleEndian.AppendUint64(ewkb, math.Float64bits(coord[0]))
+		ewkb = binary.LittleEndian.AppendUint64(ewkb, math.Float64bits(coord[1]))
+	}
+	return ewkb
+}
+
+// box3DToEWKB returns box3D as EWKB with no SRID. GEOS does not support
+// polyhedral surfaces, so a box with a Z extent is a MultiPolygon Z of its six
+// faces, each oriented outwards. A box with no Z extent is a Polygon Z. An
+// empty box is an empty polygon.
+func box3DToEWKB(box3D *geos.Box3D) []byte {
+	if box3D.MinX > box3D.MaxX || box3D.MinY > box3D.MaxY || box3D.MinZ > box3D.MaxZ {
+		return box2DToEWKB(geos.NewBox2DEmpty())
+	}
+	corners := [8][3]float64{
+		{box3D.MinX, box3D.MinY, box3D.MinZ},
+		{box3D.MinX, box3D.MaxY, box3D.MinZ},
+		{box3D.MaxX, box3D.MaxY, box3D.MinZ},
+		{box3D.MaxX, box3D.MinY, box3D.MinZ},
+		{box3D.MinX, box3D.MinY, box3D.MaxZ},
+		{box3D.MinX, box3D.MaxY, box3D.MaxZ},
+		{box3D.MaxX, box3D.MaxY, box3D.MaxZ},
+		{box3D.MaxX, box3D.MinY, box3D.MaxZ},
+	}
+	faces := [][4]int{
+		{0, 1, 2, 3},
+		{4, 7, 6, 5},
+		{0, 4, 5, 1},
+		{3, 2, 6, 7},
+		{0, 3, 7, 4},
+		{1, 5, 6, 2},
+	}
+	ewkb := make([]byte, 0, 9+len(faces)*(13+5*24))
+	if box3D.MinZ == box3D.MaxZ {
+		faces = faces[:1]
+	} else {
+		ewkb = append(ewkb, 1)
+		ewkb = binary.LittleEndian.AppendUint32(ewkb, uint32(GeometryTypeMultiPolygon)|ewkbZFlag)
+		ewkb = binary.LittleEndian.AppendUint32(ewkb, uint32(len(faces)))
+	}
+	for _, face := range faces {
+		ewkb = append(ewkb, 1)
+		ewkb = binary.LittleEndian.AppendUint32(ewkb, uint32(GeometryTypePolygon)|ewkbZFlag)
+		ewkb = binary.LittleEndian.AppendUint32(ewkb, 1)
+		ewkb = binary.LittleEndian.AppendUint32(ewkb, 5)
+		for _, i := range [5]int{face[0], face[1], face[2], face[3], face[0]} {
+			for _, ordinate := range corners[i] {
+				ewkb = binary.LittleEndian.AppendUint64(ewkb, math.Float64bits(ordinate))
+			}
+		}
+	}
+	return ewkb
+}
+
+// box2DFromBox3D returns box3D without its Z bounds.
+func box2DFromBox3D(box3D *geos.Box3D) *geos.Box2D {
+	return geos.NewBox2D(box3D.MinX, box3D.MinY, box3D.MaxX, box3D.MaxY)
+}
+
+// box3DFromBox2D returns box2D with the Z bounds minZ and maxZ.
+func box3DFromBox2D(box2D *geos.Box2D, minZ, maxZ float64) *geos.Box3D {
+	return geos.NewBox3D(box2D.MinX, box2D.MinY, minZ, box2D.MaxX, box2D.MaxY, maxZ)
+}
+
+// geomEnvelope returns the 3D envelope of geom, or nil if geom is nil or
+// empty.
+func geomEnvelope(geom *geos.Geom) (*geos.Box3D, error) {
+	if geom == nil {
+		return nil, nil
+	}
+	envelope, err := ewkbEnvelope(geom.ToEWKBWithSRID())
+	if err != nil || envelope.MinX > envelope.MaxX {
+		return nil, err
+	}
+	return envelope, nil
+}
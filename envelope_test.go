@@ -0,0 +1,169 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestScanEnvelope(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, tc := range []struct {
+			ewkt          string
+			expectedBox2D *geos.Box2D
+			expectedBox3D *geos.Box3D
+		}{
+			{
+				ewkt:          "SRID=4326;LINESTRING(1 2,3 -4,-5 6)",
+				expectedBox2D: geos.NewBox2D(-5, -4, 3, 6),
+				expectedBox3D: geos.NewBox3D(-5, -4, 0, 3, 6, 0),
+			},
+			{
+				ewkt:          "GEOMETRYCOLLECTION Z (POINT Z (1 2 3),POLYGON Z ((0 0 -1,4 0 -1,4 4 5,0 0 -1)))",
+				expectedBox2D: geos.NewBox2D(0, 0, 4, 4),
+				expectedBox3D: geos.NewBox3D(0, 0, -1, 4, 4, 5),
+			},
+			{
+				ewkt:          "MULTIPOINT((1 2),(3 4))",
+				expectedBox2D: geos.NewBox2D(1, 2, 3, 4),
+				expectedBox3D: geos.NewBox3D(1, 2, 0, 3, 4, 0),
+			},
+			{
+				ewkt:          "POINT EMPTY",
+				expectedBox2D: geos.NewBox2DEmpty(),
+				expectedBox3D: geos.NewBox3DEmpty(),
+			},
+		} {
+			t.Run(tc.ewkt, func(t *testing.T) {
+				for _, format := range []int16{
+					pgx.BinaryFormatCode,
+					pgx.TextFormatCode,
+				} {
+					t.Run(strconv.Itoa(int(format)), func(t *testing.T) {
+						var actualBox2D geos.Box2D
+						assert.NoError(t, conn.QueryRow(ctx, "select $1::text::geometry", pgx.QueryResultFormats{format}, tc.ewkt).Scan(&actualBox2D))
+						assert.Equal(t, *tc.expectedBox2D, actualBox2D)
+
+						var actualBox3D geos.Box3D
+						assert.NoError(t, conn.QueryRow(ctx, "select $1::text::geometry", pgx.QueryResultFormats{format}, tc.ewkt).Scan(&actualBox3D))
+						assert.Equal(t, *tc.expectedBox3D, actualBox3D)
+					})
+				}
+			})
+		}
+	})
+}
+
+func TestEncodeBox2DAsGeometry(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		var actual string
+		assert.NoError(tb, conn.QueryRow(ctx, "select ST_AsEWKT($1::geometry)", geos.NewBox2D(1, 2, 3, 4)).Scan(&actual))
+		assert.Equal(tb, "POLYGON((1 2,1 4,3 4,3 2,1 2))", actual)
+
+		assert.NoError(tb, conn.QueryRow(ctx, "select ST_AsEWKT($1::geometry)", *geos.NewBox2DEmpty()).Scan(&actual))
+		assert.Equal(tb, "POLYGON EMPTY", actual)
+
+		_, err := pgxgeos.RegisterWithOptions(ctx, conn, pgxgeos.WithDefaultSRID(4326))
+		assert.NoError(tb, err)
+		assert.NoError(tb, conn.QueryRow(ctx, "select ST_AsEWKT($1::geometry)", geos.NewBox2D(1, 2, 3, 4)).Scan(&actual))
+		assert.Equal(tb, "SRID=4326;POLYGON((1 2,1 4,3 4,3 2,1 2))", actual)
+	})
+}
+
+func TestEncodeBox3DAsGeometry(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		var actual string
+		assert.NoError(tb, conn.QueryRow(ctx, "select ST_AsEWKT($1::geometry)", geos.NewBox3D(1, 2, 3, 4, 5, 3)).Scan(&actual))
+		assert.Equal(tb, "POLYGON((1 2 3,1 5 3,4 5 3,4 2 3,1 2 3))", actual)
+
+		var numGeometries int
+		var box3D geos.Box3D
+		assert.NoError(tb, conn.QueryRow(ctx, "select ST_NumGeometries(geom), ST_3DExtent(geom) from (select $1::geometry as geom) as t group by geom", geos.NewBox3D(1, 2, 3, 4, 6, 8)).Scan(&numGeometries, &box3D))
+		assert.Equal(tb, 6, numGeometries)
+		assert.Equal(tb, *geos.NewBox3D(1, 2, 3, 4, 6, 8), box3D)
+	})
+}
+
+func TestBoxConversions(t *testing.T) {
+	m := newBoxTestMap()
+
+	var box2D geos.Box2D
+	assert.NoError(t, m.Scan(box3DTestOID, pgx.TextFormatCode, []byte("BOX3D(1 2 3,4 5 6)"), &box2D))
+	assert.Equal(t, *geos.NewBox2D(1, 2, 4, 5), box2D)
+
+	var box3D geos.Box3D
+	assert.NoError(t, m.Scan(box2DTestOID, pgx.TextFormatCode, []byte("BOX(1 2,3 4)"), &box3D))
+	assert.Equal(t, *geos.NewBox3D(1, 2, 0, 3, 4, 0), box3D)
+
+	text, err := m.Encode(box2DTestOID, pgx.TextFormatCode, geos.NewBox3D(1, 2, 3, 4, 5, 6), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "BOX(1 2,4 5)", string(text))
+
+	text, err = m.Encode(box3DTestOID, pgx.TextFormatCode, pgxgeos.Box2D{Box2D: *geos.NewBox2D(1, 2, 3, 4)}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "BOX3D(1 2 0,3 4 0)", string(text))
+
+	m = pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Name: "box2d", OID: box2DTestOID, Codec: pgxgeos.NewBox2DCodec(pgxgeos.WithDefaultBoxZ(-1, 1))})
+	m.RegisterType(&pgtype.Type{Name: "box3d", OID: box3DTestOID, Codec: pgxgeos.NewBox3DCodec(pgxgeos.WithDefaultBoxZ(-1, 1))})
+
+	var wrappedBox3D pgxgeos.Box3D
+	assert.NoError(t, m.Scan(box2DTestOID, pgx.TextFormatCode, []byte("BOX(1 2,3 4)"), &wrappedBox3D))
+	assert.Equal(t, *geos.NewBox3D(1, 2, -1, 3, 4, 1), wrappedBox3D.Box3D)
+
+	text, err = m.Encode(box3DTestOID, pgx.TextFormatCode, *geos.NewBox2D(1, 2, 3, 4), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "BOX3D(1 2 -1,3 4 1)", string(text))
+}
+
+func TestBoxGeometryConversions(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+
+		var box2D geos.Box2D
+		assert.NoError(tb, conn.QueryRow(ctx, "select ST_3DExtent(geom) from (values ('POINT Z (1 2 3)'::geometry), ('POINT Z (4 5 6)'::geometry)) as t(geom)").Scan(&box2D))
+		assert.Equal(tb, *geos.NewBox2D(1, 2, 4, 5), box2D)
+
+		var box3D geos.Box3D
+		assert.NoError(tb, conn.QueryRow(ctx, "select ST_Extent(geom) from (values ('POINT(1 2)'::geometry), ('POINT(3 4)'::geometry)) as t(geom)").Scan(&box3D))
+		assert.Equal(tb, *geos.NewBox3D(1, 2, 0, 3, 4, 0), box3D)
+
+		var geom *geos.Geom
+		assert.NoError(tb, conn.QueryRow(ctx, "select 'BOX(1 2,3 4)'::box2d").Scan(&geom))
+		assert.True(tb, geom.Equals(mustNewGeomFromWKT(tb, "POLYGON ((1 2, 1 4, 3 4, 3 2, 1 2))")))
+
+		assert.NoError(tb, conn.QueryRow(ctx, "select 'BOX3D(1 2 3,4 5 6)'::box3d").Scan(&geom))
+		assert.NoError(tb, conn.QueryRow(ctx, "select ST_3DExtent($1::geometry)", geom).Scan(&box3D))
+		assert.Equal(tb, *geos.NewBox3D(1, 2, 3, 4, 5, 6), box3D)
+
+		var nullGeom pgxgeos.NullGeom
+		assert.NoError(tb, conn.QueryRow(ctx, "select NULL::box3d").Scan(&nullGeom))
+		assert.False(tb, nullGeom.Valid)
+
+		assert.NoError(tb, conn.QueryRow(ctx, "select $1::box2d", geos.NewBox3D(1, 2, 3, 4, 5, 6)).Scan(&box2D))
+		assert.Equal(tb, *geos.NewBox2D(1, 2, 4, 5), box2D)
+
+		assert.NoError(tb, conn.QueryRow(ctx, "select $1::box3d", geos.NewBox2D(1, 2, 3, 4)).Scan(&box3D))
+		assert.Equal(tb, *geos.NewBox3D(1, 2, 0, 3, 4, 0), box3D)
+
+		assert.NoError(tb, conn.QueryRow(ctx, "select $1::box2d", mustNewGeomFromWKT(tb, "LINESTRING (1 2, -3 4)")).Scan(&box2D))
+		assert.Equal(tb, *geos.NewBox2D(-3, 2, 1, 4), box2D)
+
+		assert.NoError(tb, conn.QueryRow(ctx, "select $1::box3d", mustNewGeomFromWKT(tb, "LINESTRING Z (1 2 3, -3 4 -5)")).Scan(&box3D))
+		assert.Equal(tb, *geos.NewBox3D(-3, 2, -5, 1, 4, 3), box3D)
+
+		var nullBox *geos.Box2D
+		assert.NoError(tb, conn.QueryRow(ctx, "select $1::box2d", mustNewGeomFromWKT(tb, "POINT EMPTY")).Scan(&nullBox))
+		assert.Zero(tb, nullBox)
+	})
+}
@@ -0,0 +1,300 @@
+package pgxgeos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// EWKB is a geometry in EWKB format. Scanning into an EWKB copies the raw
+// EWKB without parsing it, and encoding an EWKB sends it unchanged, apart from
+// the default SRID and typmod, so geometries can be forwarded without GEOS.
+// Unlike a []byte, an EWKB receives EWKB in both binary and text format. A nil
+// EWKB is NULL.
+type EWKB []byte
+
+// Flags set in the geometry type of EWKB geometries.
+const (
+	ewkbZFlag    = 0x80000000
+	ewkbMFlag    = 0x40000000
+	ewkbSRIDFlag = 0x20000000
+)
+
+// An ewkbHeader is the header of a geometry in EWKB format.
+type ewkbHeader struct {
+	byteOrder    binary.ByteOrder
+	geometryType GeometryType
+	z            bool
+	m            bool
+	hasSRID      bool
+	srid         int
+	size         int
+}
+
+// ewkbByteOrder returns the byte order of ewkb, indicated by its first byte.
+func ewkbByteOrder(ewkb []byte) binary.ByteOrder {
+	if ewkb[0] == 0 {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// parseEWKBHeader parses the header of the geometry at the start of ewkb.
+// ISO WKB geometry types, which encode Z and M by adding 1000, 2000, or 3000,
+// are also accepted.
+func parseEWKBHeader(ewkb []byte) (ewkbHeader, error) {
+	if len(ewkb) < 5 {
+		return ewkbHeader{}, fmt.Errorf("short header: %w", ErrInvalidEWKB)
+	}
+	if ewkb[0] > 1 {
+		return ewkbHeader{}, fmt.Errorf("%d: invalid byte order: %w", ewkb[0], ErrInvalidEWKB)
+	}
+	header := ewkbHeader{
+		byteOrder: ewkbByteOrder(ewkb),
+		size:      5,
+	}
+	word := header.byteOrder.Uint32(ewkb[1:5])
+	header.z = word&ewkbZFlag != 0
+	header.m = word&ewkbMFlag != 0
+	header.hasSRID = word&ewkbSRIDFlag != 0
+	header.geometryType = GeometryType(word &^ (ewkbZFlag | ewkbMFlag | ewkbSRIDFlag))
+	if header.geometryType >= 1000 {
+		switch header.geometryType / 1000 {
+		case 1:
+			header.z = true
+		case 2:
+			header.m = true
+		case 3:
+			header.z, header.m = true, true
+		}
+		header.geometryType %= 1000
+	}
+	if header.hasSRID {
+		if len(ewkb) < 9 {
+			return ewkbHeader{}, fmt.Errorf("short header: %w", ErrInvalidEWKB)
+		}
+		header.srid = int(int32(header.byteOrder.Uint32(ewkb[5:9]))) //nolint:gosec
+		header.size = 9
+	}
+	return header, nil
+}
+
+// typeWord returns the EWKB geometry type of h with the SRID flag set if
+// includeSRID is set and h has an SRID.
+func (h ewkbHeader) typeWord(includeSRID bool) uint32 {
+	word := uint32(h.geometryType)
+	if h.z {
+		word |= ewkbZFlag
+	}
+	if h.m {
+		word |= ewkbMFlag
+	}
+	if includeSRID && h.hasSRID {
+		word |= ewkbSRIDFlag
+	}
+	return word
+}
+
+// dimensions returns the number of ordinates in each coordinate of h.
+func (h ewkbHeader) dimensions() int {
+	dimensions := 2
+	if h.z {
+		dimensions++
+	}
+	if h.m {
+		dimensions++
+	}
+	return dimensions
+}
+
+// setEWKBDefaultSRID returns ewkb with its SRID set to srid if it does not
+// already have a non-zero SRID. ewkb is not modified.
+func setEWKBDefaultSRID(ewkb []byte, srid int) []byte {
+	if len(ewkb) < 5 {
+		return ewkb
+	}
+	byteOrder := ewkbByteOrder(ewkb)
+	if byteOrder.Uint32(ewkb[1:5])&ewkbSRIDFlag != 0 && (len(ewkb) < 9 || byteOrder.Uint32(ewkb[5:9]) != 0) {
+		return ewkb
+	}
+	return appendEWKBDefaultSRID(make([]byte, 0, len(ewkb)+4), ewkb, srid)
+}
+
+// appendEWKBDefaultSRID appends ewkb to dst with its SRID set to srid if it
+// does not already have a non-zero SRID.
+func appendEWKBDefaultSRID(dst, ewkb []byte, srid int) []byte {
+	if len(ewkb) < 5 {
+		return append(dst, ewkb...)
+	}
+	byteOrder := ewkbByteOrder(ewkb)
+	geomType := byteOrder.Uint32(ewkb[1:5])
+	if geomType&ewkbSRIDFlag != 0 {
+		if len(ewkb) < 9 || byteOrder.Uint32(ewkb[5:9]) != 0 {
+			return append(dst, ewkb...)
+		}
+		dst = append(dst, ewkb[:5]...)
+		dst = appendUint32(dst, byteOrder, uint32(srid))
+		return append(dst, ewkb[9:]...)
+	}
+	dst = append(dst, ewkb[0])
+	dst = appendUint32(dst, byteOrder, geomType|ewkbSRIDFlag)
+	dst = appendUint32(dst, byteOrder, uint32(srid))
+	return append(dst, ewkb[5:]...)
+}
+
+// setEWKBDimensions returns ewkb with its Z ordinates removed unless z is set
+// and its M ordinates removed unless m is set.
+func setEWKBDimensions(ewkb []byte, z, m bool) ([]byte, error) {
+	result, rest, err := appendEWKBDimensions(make([]byte, 0, len(ewkb)), ewkb, z, m)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%d trailing bytes: %w", len(rest), ErrInvalidEWKB)
+	}
+	return result, nil
+}
+
+// appendEWKBDimensions appends the geometry at the start of ewkb to dst with
+// only the Z and M ordinates selected by z and m, and returns the remainder
+// of ewkb.
+func appendEWKBDimensions(dst, ewkb []byte, z, m bool) ([]byte, []byte, error) {
+	header, err := parseEWKBHeader(ewkb)
+	if err != nil {
+		return nil, nil, err
+	}
+	outHeader := header
+	outHeader.z = header.z && z
+	outHeader.m = header.m && m
+	dst = append(dst, ewkb[0])
+	dst = appendUint32(dst, header.byteOrder, outHeader.typeWord(true))
+	dst = append(dst, ewkb[5:header.size]...)
+	ewkb = ewkb[header.size:]
+
+	var n uint32
+	switch header.geometryType {
+	case GeometryTypePoint:
+		return appendEWKBCoords(dst, ewkb, 1, header, outHeader)
+	case GeometryTypeLineString, GeometryTypeCircularString:
+		if n, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+			return nil, nil, err
+		}
+		dst = appendUint32(dst, header.byteOrder, n)
+		return appendEWKBCoords(dst, ewkb, n, header, outHeader)
+	case GeometryTypePolygon, GeometryTypeTriangle:
+		if n, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+			return nil, nil, err
+		}
+		dst = appendUint32(dst, header.byteOrder, n)
+		for range n {
+			var points uint32
+			if points, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+				return nil, nil, err
+			}
+			dst = appendUint32(dst, header.byteOrder, points)
+			if dst, ewkb, err = appendEWKBCoords(dst, ewkb, points, header, outHeader); err != nil {
+				return nil, nil, err
+			}
+		}
+		return dst, ewkb, nil
+	case GeometryTypeMultiPoint, GeometryTypeMultiLineString, GeometryTypeMultiPolygon, GeometryTypeGeometryCollection,
+		GeometryTypeCompoundCurve, GeometryTypeCurvePolygon, GeometryTypeMultiCurve, GeometryTypeMultiSurface, GeometryTypePolyhedralSurface, GeometryTypeTIN:
+		if n, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+			return nil, nil, err
+		}
+		dst = appendUint32(dst, header.byteOrder, n)
+		for range n {
+			if dst, ewkb, err = appendEWKBDimensions(dst, ewkb, z, m); err != nil {
+				return nil, nil, err
+			}
+		}
+		return dst, ewkb, nil
+	default:
+		return nil, nil, fmt.Errorf("%s: unsupported geometry type: %w", header.geometryType, ErrInvalidEWKB)
+	}
+}
+
+// appendEWKBCoords appends n coordinates from ewkb, which have the ordinates
+// of header, to dst with the ordinates of outHeader, and returns the remainder
+// of ewkb.
+func appendEWKBCoords(dst, ewkb []byte, n uint32, header, outHeader ewkbHeader) ([]byte, []byte, error) {
+	size := 8 * header.dimensions()
+	if uint64(len(ewkb)) < uint64(n)*uint64(size) {
+		return nil, nil, fmt.Errorf("short coordinates: %w", ErrInvalidEWKB)
+	}
+	for range n {
+		dst = append(dst, ewkb[:16]...)
+		offset := 16
+		if header.z {
+			if outHeader.z {
+				dst = append(dst, ewkb[offset:offset+8]...)
+			}
+			offset += 8
+		}
+		if header.m && outHeader.m {
+			dst = append(dst, ewkb[offset:offset+8]...)
+		}
+		ewkb = ewkb[size:]
+	}
+	return dst, ewkb, nil
+}
+
+// promoteEWKBToMulti returns ewkb, which must be a Point, LineString, or
+// Polygon, as a MultiPoint, MultiLineString, or MultiPolygon containing it.
+func promoteEWKBToMulti(ewkb []byte) ([]byte, error) {
+	header, err := parseEWKBHeader(ewkb)
+	if err != nil {
+		return nil, err
+	}
+	switch header.geometryType {
+	case GeometryTypePoint, GeometryTypeLineString, GeometryTypePolygon:
+	default:
+		return nil, fmt.Errorf("%s: cannot promote geometry type to multi: %w", header.geometryType, ErrInvalidEWKB)
+	}
+	multiHeader := header
+	multiHeader.geometryType = header.geometryType.multi()
+	result := make([]byte, 0, len(ewkb)+9)
+	result = append(result, ewkb[0])
+	result = appendUint32(result, header.byteOrder, multiHeader.typeWord(true))
+	result = append(result, ewkb[5:header.size]...)
+	result = appendUint32(result, header.byteOrder, 1)
+	result = append(result, ewkb[0])
+	result = appendUint32(result, header.byteOrder, header.typeWord(false))
+	result = append(result, ewkb[header.size:]...)
+	return result, nil
+}
+
+// appendUint32 appends v to dst in byteOrder.
+func appendUint32(dst []byte, byteOrder binary.ByteOrder, v uint32) []byte {
+	if byteOrder == binary.BigEndian {
+		return binary.BigEndian.AppendUint32(dst, v)
+	}
+	return binary.LittleEndian.AppendUint32(dst, v)
+}
+
+// MarshalEWKB implements [EWKBMarshaler.MarshalEWKB].
+func (e EWKB) MarshalEWKB() ([]byte, error) {
+	return e, nil
+}
+
+// UnmarshalEWKB implements [EWKBUnmarshaler.UnmarshalEWKB].
+func (e *EWKB) UnmarshalEWKB(ewkb []byte) error {
+	*e = bytes.Clone(ewkb)
+	return nil
+}
+
+// readFloat64 reads a float64 in byteOrder from the start of ewkb, which must
+// contain at least eight bytes.
+func readFloat64(ewkb []byte, byteOrder binary.ByteOrder) float64 {
+	return math.Float64frombits(byteOrder.Uint64(ewkb[:8]))
+}
+
+// readUint32 reads a uint32 in byteOrder from the start of ewkb and returns it
+// and the remainder of ewkb.
+func readUint32(ewkb []byte, byteOrder binary.ByteOrder) (uint32, []byte, error) {
+	if len(ewkb) < 4 {
+		return 0, nil, fmt.Errorf("short count: %w", ErrInvalidEWKB)
+	}
+	return byteOrder.Uint32(ewkb[:4]), ewkb[4:], nil
+}
@@ -0,0 +1,123 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestScanRawEWKB(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, format := range []int16{
+			pgx.BinaryFormatCode,
+			pgx.TextFormatCode,
+		} {
+			tb.(*testing.T).Run(strconv.Itoa(int(format)), func(t *testing.T) { //nolint:forcetypeassert
+				geom := mustNewGeomFromWKT(t, "LINESTRING(1 2,3 4)").SetSRID(4326)
+				expected := geom.ToEWKBWithSRID()
+
+				var actualEWKB pgxgeos.EWKB
+				assert.NoError(t, conn.QueryRow(ctx, "select $1::geometry", pgx.QueryResultFormats{format}, geom).Scan(&actualEWKB))
+				assert.Equal(t, pgxgeos.EWKB(expected), actualEWKB)
+
+				var actualString string
+				assert.NoError(t, conn.QueryRow(ctx, "select $1::geometry", pgx.QueryResultFormats{format}, geom).Scan(&actualString))
+				actualStringEWKB, err := hex.DecodeString(actualString)
+				assert.NoError(t, err)
+				assert.Equal(t, expected, actualStringEWKB)
+
+				// In text format pgx scans *[]byte targets itself, so they
+				// receive hex-encoded EWKB.
+				var actualBytes []byte
+				assert.NoError(t, conn.QueryRow(ctx, "select $1::geometry", pgx.QueryResultFormats{format}, geom).Scan(&actualBytes))
+				if format == pgx.TextFormatCode {
+					assert.Equal(t, hex.AppendEncode(nil, expected), actualBytes)
+				} else {
+					assert.Equal(t, expected, actualBytes)
+				}
+
+				var actualRawMessage json.RawMessage
+				assert.NoError(t, conn.QueryRow(ctx, "select $1::geometry", pgx.QueryResultFormats{format}, geom).Scan(&actualRawMessage))
+				assert.Equal(t, `{"type":"LineString","coordinates":[[1,2],[3,4]]}`, string(actualRawMessage))
+
+				var actualNullEWKB pgxgeos.EWKB
+				assert.NoError(t, conn.QueryRow(ctx, "select NULL::geometry", pgx.QueryResultFormats{format}).Scan(&actualNullEWKB))
+				assert.Zero(t, actualNullEWKB)
+
+				var actualGeomText string
+				assert.NoError(t, conn.QueryRow(ctx, "select ST_AsEWKT($1::geometry)", pgx.QueryResultFormats{format}, actualEWKB).Scan(&actualGeomText))
+				assert.Equal(t, "SRID=4326;LINESTRING(1 2,3 4)", actualGeomText)
+			})
+		}
+	})
+}
+
+func TestScanGeoJSONRawMessage(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, tc := range []struct {
+			ewkt     string
+			expected string
+		}{
+			{
+				ewkt:     "POINT EMPTY",
+				expected: `{"type":"Point","coordinates":[]}`,
+			},
+			{
+				ewkt:     "SRID=4326;POINT Z (1.5 -2 3)",
+				expected: `{"type":"Point","coordinates":[1.5,-2,3]}`,
+			},
+			{
+				ewkt:     "POINT M (1 2 3)",
+				expected: `{"type":"Point","coordinates":[1,2]}`,
+			},
+			{
+				ewkt:     "POLYGON((0 0,1 0,1 1,0 0),(0.25 0.25,0.5 0.25,0.5 0.5,0.25 0.25))",
+				expected: `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]],[[0.25,0.25],[0.5,0.25],[0.5,0.5],[0.25,0.25]]]}`,
+			},
+			{
+				ewkt:     "MULTIPOINT((1 2),(3 4))",
+				expected: `{"type":"MultiPoint","coordinates":[[1,2],[3,4]]}`,
+			},
+			{
+				ewkt:     "MULTIPOLYGON(((0 0,1 0,1 1,0 0)))",
+				expected: `{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]]]}`,
+			},
+			{
+				ewkt:     "GEOMETRYCOLLECTION(POINT(1 2),LINESTRING(3 4,5 6))",
+				expected: `{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"LineString","coordinates":[[3,4],[5,6]]}]}`,
+			},
+		} {
+			t.Run(tc.ewkt, func(t *testing.T) {
+				var actual json.RawMessage
+				assert.NoError(t, conn.QueryRow(ctx, "select $1::text::geometry", tc.ewkt).Scan(&actual))
+				assert.Equal(t, tc.expected, string(actual))
+			})
+		}
+	})
+}
+
+func TestScanGeoJSONRawMessageFloats(t *testing.T) {
+	m := newGeometryTestMap()
+	for _, coords := range [][]float64{
+		{0, -0.5},
+		{1e-7, -1e-7},
+		{1.5e-10, 2e20},
+		{1e21, -1.25e-300},
+	} {
+		ewkb := pgxgeos.NewEWKBWriter(0).AppendPointXY(nil, coords[0], coords[1])
+		var actual json.RawMessage
+		assert.NoError(t, m.Scan(geometryTestOID, pgx.BinaryFormatCode, ewkb, &actual))
+		expectedCoords, err := json.Marshal(coords)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"type":"Point","coordinates":`+string(expectedCoords)+`}`, string(actual))
+	}
+}
@@ -0,0 +1,152 @@
+package pgxgeos
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// emptyOrdinateBits are the bits of the NaN ordinates of empty Points, as
+// written by PostGIS.
+const emptyOrdinateBits = 0x7ff8000000000000
+
+// An EWKBWriter writes Points, LineStrings, and Polygons in little-endian
+// EWKB format from coordinates, without GEOS. It is useful for bulk loads,
+// for example with [github.com/jackc/pgx/v5.Conn.CopyFrom], where creating a
+// [*github.com/twpayne/go-geos.Geom] for each row would dominate.
+//
+// Coordinates are slices of two, three, or four ordinates, as used by
+// [github.com/twpayne/go-geos], giving geometries with XY, XYZ, or XYZM
+// ordinates. All coordinates in a geometry must have the same number of
+// ordinates.
+//
+// The Append methods append to a caller-supplied buffer, so that buffers can
+// be reused. On error they return the buffer unchanged. The results can be
+// converted to [EWKB], which can be encoded as geometries.
+type EWKBWriter struct {
+	srid int
+}
+
+// NewEWKBWriter returns a new EWKBWriter that writes geometries with srid. If
+// srid is zero then geometries are written without an SRID.
+func NewEWKBWriter(srid int) *EWKBWriter {
+	return &EWKBWriter{
+		srid: srid,
+	}
+}
+
+// AppendLineString appends a LineString of coords to dst.
+func (w *EWKBWriter) AppendLineString(dst []byte, coords [][]float64) ([]byte, error) {
+	dimensions, err := coordsDimensions(coords, 0)
+	if err != nil {
+		return dst, err
+	}
+	dst = w.appendHeader(dst, GeometryTypeLineString, max(dimensions, 2))
+	return appendEWKBCoordSeq(dst, coords), nil
+}
+
+// AppendPoint appends a Point at coord to dst. If coord is empty then an empty
+// Point is appended.
+func (w *EWKBWriter) AppendPoint(dst []byte, coord []float64) ([]byte, error) {
+	if len(coord) == 0 {
+		dst = w.appendHeader(dst, GeometryTypePoint, 2)
+		dst = binary.LittleEndian.AppendUint64(dst, emptyOrdinateBits)
+		return binary.LittleEndian.AppendUint64(dst, emptyOrdinateBits), nil
+	}
+	dimensions, err := coordsDimensions([][]float64{coord}, 0)
+	if err != nil {
+		return dst, err
+	}
+	dst = w.appendHeader(dst, GeometryTypePoint, dimensions)
+	for _, ordinate := range coord {
+		dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(ordinate))
+	}
+	return dst, nil
+}
+
+// AppendPointXY appends a Point at x, y to dst. It is the fastest way to write
+// points.
+func (w *EWKBWriter) AppendPointXY(dst []byte, x, y float64) []byte {
+	dst = w.appendHeader(dst, GeometryTypePoint, 2)
+	dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(x))
+	return binary.LittleEndian.AppendUint64(dst, math.Float64bits(y))
+}
+
+// AppendPolygon appends a Polygon with rings to dst. The first ring is the
+// exterior ring and any subsequent rings are holes. Rings are not closed or
+// otherwise validated.
+func (w *EWKBWriter) AppendPolygon(dst []byte, rings [][][]float64) ([]byte, error) {
+	dimensions := 0
+	for _, ring := range rings {
+		var err error
+		if dimensions, err = coordsDimensions(ring, dimensions); err != nil {
+			return dst, err
+		}
+	}
+	dst = w.appendHeader(dst, GeometryTypePolygon, max(dimensions, 2))
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(rings))) //nolint:gosec
+	for _, ring := range rings {
+		dst = appendEWKBCoordSeq(dst, ring)
+	}
+	return dst, nil
+}
+
+// LineString returns a new LineString of coords.
+func (w *EWKBWriter) LineString(coords [][]float64) (EWKB, error) {
+	return w.AppendLineString(nil, coords)
+}
+
+// Point returns a new Point at coord.
+func (w *EWKBWriter) Point(coord []float64) (EWKB, error) {
+	return w.AppendPoint(nil, coord)
+}
+
+// Polygon returns a new Polygon with rings.
+func (w *EWKBWriter) Polygon(rings [][][]float64) (EWKB, error) {
+	return w.AppendPolygon(nil, rings)
+}
+
+// appendHeader appends an EWKB header for geometryType with dimensions
+// ordinates and w's SRID to dst.
+func (w *EWKBWriter) appendHeader(dst []byte, geometryType GeometryType, dimensions int) []byte {
+	header := ewkbHeader{
+		geometryType: geometryType,
+		z:            dimensions >= 3,
+		m:            dimensions == 4,
+		hasSRID:      w.srid != 0,
+	}
+	dst = append(dst, 1)
+	dst = binary.LittleEndian.AppendUint32(dst, header.typeWord(true))
+	if header.hasSRID {
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(w.srid)) //nolint:gosec
+	}
+	return dst
+}
+
+// appendEWKBCoordSeq appends the number of coords followed by coords to dst.
+func appendEWKBCoordSeq(dst []byte, coords [][]float64) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(coords))) //nolint:gosec
+	for _, coord := range coords {
+		for _, ordinate := range coord {
+			dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(ordinate))
+		}
+	}
+	return dst
+}
+
+// coordsDimensions returns the number of ordinates of coords, which must be
+// dimensions if dimensions is non-zero. It returns dimensions if coords is
+// empty.
+func coordsDimensions(coords [][]float64, dimensions int) (int, error) {
+	for i, coord := range coords {
+		switch {
+		case len(coord) < 2 || len(coord) > 4:
+			return 0, fmt.Errorf("coordinate %d: %d ordinates: %w", i, len(coord), ErrDimensionMismatch)
+		case dimensions == 0:
+			dimensions = len(coord)
+		case len(coord) != dimensions:
+			return 0, fmt.Errorf("coordinate %d: expected %d ordinates, got %d: %w", i, dimensions, len(coord), ErrDimensionMismatch)
+		}
+	}
+	return dimensions, nil
+}
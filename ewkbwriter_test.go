@@ -0,0 +1,154 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestEWKBWriter(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		write       func(*pgxgeos.EWKBWriter) (pgxgeos.EWKB, error)
+		srid        int
+		expectedHex string
+	}{
+		{
+			name: "point_xy",
+			write: func(w *pgxgeos.EWKBWriter) (pgxgeos.EWKB, error) {
+				return w.AppendPointXY(nil, 1, 2), nil
+			},
+			srid:        4326,
+			expectedHex: "0101000020e6100000000000000000f03f0000000000000040",
+		},
+		{
+			name: "point",
+			write: func(w *pgxgeos.EWKBWriter) (pgxgeos.EWKB, error) {
+				return w.Point([]float64{1, 2})
+			},
+			expectedHex: "0101000000000000000000f03f0000000000000040",
+		},
+		{
+			name: "point_z",
+			write: func(w *pgxgeos.EWKBWriter) (pgxgeos.EWKB, error) {
+				return w.Point([]float64{1, 2, 3})
+			},
+			srid:        4326,
+			expectedHex: "01010000a0e6100000000000000000f03f00000000000000400000000000000840",
+		},
+		{
+			name: "point_empty",
+			write: func(w *pgxgeos.EWKBWriter) (pgxgeos.EWKB, error) {
+				return w.Point(nil)
+			},
+			expectedHex: "0101000000000000000000f87f000000000000f87f",
+		},
+		{
+			name: "linestring",
+			write: func(w *pgxgeos.EWKBWriter) (pgxgeos.EWKB, error) {
+				return w.LineString([][]float64{{1, 2}, {3, 4}})
+			},
+			expectedHex: "010200000002000000000000000000f03f000000000000004000000000000008400000000000001040",
+		},
+		{
+			name: "linestring_empty",
+			write: func(w *pgxgeos.EWKBWriter) (pgxgeos.EWKB, error) {
+				return w.LineString(nil)
+			},
+			expectedHex: "010200000000000000",
+		},
+		{
+			name: "polygon",
+			write: func(w *pgxgeos.EWKBWriter) (pgxgeos.EWKB, error) {
+				return w.Polygon([][][]float64{{{0, 0}, {1, 0}, {0, 1}, {0, 0}}})
+			},
+			expectedHex: "01030000000100000004000000" +
+				"00000000000000000000000000000000" +
+				"000000000000f03f0000000000000000" +
+				"0000000000000000000000000000f03f" +
+				"00000000000000000000000000000000",
+		},
+		{
+			name: "polygon_empty",
+			write: func(w *pgxgeos.EWKBWriter) (pgxgeos.EWKB, error) {
+				return w.Polygon(nil)
+			},
+			srid:        4326,
+			expectedHex: "0103000020e610000000000000",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ewkb, err := tc.write(pgxgeos.NewEWKBWriter(tc.srid))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedHex, hex.EncodeToString(ewkb))
+
+			m := newGeometryTestMap()
+			for format := range formatNames {
+				var actual pgxgeos.EWKB
+				buf, err := m.Encode(geometryTestOID, format, ewkb, nil)
+				assert.NoError(t, err)
+				assert.NoError(t, m.Scan(geometryTestOID, format, buf, &actual))
+				assert.Equal(t, ewkb, actual)
+			}
+		})
+	}
+}
+
+func TestEWKBWriterDimensionMismatch(t *testing.T) {
+	w := pgxgeos.NewEWKBWriter(0)
+	_, err := w.Point([]float64{1})
+	assert.IsError(t, err, pgxgeos.ErrDimensionMismatch)
+	_, err = w.LineString([][]float64{{1, 2}, {3, 4, 5}})
+	assert.IsError(t, err, pgxgeos.ErrDimensionMismatch)
+	_, err = w.Polygon([][][]float64{{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 0}}, {{0, 0}}})
+	assert.IsError(t, err, pgxgeos.ErrDimensionMismatch)
+
+	buf := []byte{1, 2, 3}
+	for _, appendFunc := range []func() ([]byte, error){
+		func() ([]byte, error) { return w.AppendPoint(buf, []float64{1}) },
+		func() ([]byte, error) { return w.AppendLineString(buf, [][]float64{{1, 2}, {3, 4, 5}}) },
+		func() ([]byte, error) { return w.AppendPolygon(buf, [][][]float64{{{0, 0}}, {{0, 0, 0}}}) },
+	} {
+		actual, err := appendFunc()
+		assert.IsError(t, err, pgxgeos.ErrDimensionMismatch)
+		assert.Equal(t, []byte{1, 2, 3}, actual)
+	}
+
+	ewkb, err := w.Polygon([][][]float64{{}, {{0, 0, 0}}})
+	assert.NoError(t, err)
+	header, err := pgxgeos.InspectEWKB(ewkb)
+	assert.NoError(t, err)
+	assert.True(t, header.Z)
+}
+
+func TestEWKBWriterCopyFrom(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		_, err := conn.Exec(ctx, "create temporary table points (id integer, geom geometry)")
+		assert.NoError(tb, err)
+
+		w := pgxgeos.NewEWKBWriter(4326)
+		n := int64(1000)
+		i := int64(0)
+		var buf []byte
+		copied, err := conn.CopyFrom(ctx, pgx.Identifier{"points"}, []string{"id", "geom"}, pgx.CopyFromFunc(func() ([]any, error) {
+			if i == n {
+				return nil, nil
+			}
+			i++
+			buf = w.AppendPointXY(buf[:0], float64(i), -float64(i))
+			return []any{i, pgxgeos.EWKB(buf)}, nil
+		}))
+		assert.NoError(tb, err)
+		assert.Equal(tb, n, copied)
+
+		var actual string
+		assert.NoError(tb, conn.QueryRow(ctx, "select ST_AsEWKT(geom) from points where id = 500").Scan(&actual))
+		assert.Equal(tb, "SRID=4326;POINT(500 -500)", actual)
+	})
+}
@@ -0,0 +1,274 @@
+package pgxgeos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/twpayne/go-geos"
+)
+
+// A geometryFormat is a format in which geometries are represented by
+// non-geometry types.
+type geometryFormat int
+
+// Geometry formats.
+const (
+	// geometryFormatWKB is WKB or EWKB, as returned by ST_AsBinary and
+	// ST_AsEWKB.
+	geometryFormatWKB geometryFormat = iota
+	// geometryFormatText is WKT, EWKT, hex-encoded EWKB, or GeoJSON, as
+	// returned by ST_AsText, ST_AsEWKT, geometry::text, and ST_AsGeoJSON.
+	geometryFormatText
+	// geometryFormatGeoJSON is GeoJSON, as returned by ST_AsGeoJSON cast to
+	// json or jsonb.
+	geometryFormatGeoJSON
+)
+
+// geometryFormatTypes are the non-geometry types whose codecs are wrapped to
+// scan and encode geometries, and the format of the geometries in them.
+var geometryFormatTypes = []struct {
+	name   string
+	format geometryFormat
+}{
+	{"bytea", geometryFormatWKB},
+	{"json", geometryFormatGeoJSON},
+	{"jsonb", geometryFormatGeoJSON},
+	{"text", geometryFormatText},
+	{"varchar", geometryFormatText},
+}
+
+// A geometryFormatCodec wraps the [github.com/jackc/pgx/v5/pgtype.Codec] of a
+// non-geometry type, such as bytea, text, or json, so that it can also scan
+// and encode geometries represented in format.
+type geometryFormatCodec struct {
+	pgtype.Codec
+	geometryCodec *geometryCodec
+	format        geometryFormat
+}
+
+// A geometryFormatEncodePlan implements
+// [github.com/jackc/pgx/v5/pgtype.EncodePlan] for
+// [*github.com/twpayne/go-geos.Geom] types represented in a non-geometry type.
+// It converts the geometry to a []byte or string and encodes it with
+// encodePlan.
+type geometryFormatEncodePlan struct {
+	codec      *geometryFormatCodec
+	encodePlan pgtype.EncodePlan
+}
+
+// A geometryFormatScanPlan implements
+// [github.com/jackc/pgx/v5/pgtype.ScanPlan] for
+// [*github.com/twpayne/go-geos.Geom] types represented in a non-geometry type.
+// It scans the value as a []byte with bytesScanPlan and then parses it.
+type geometryFormatScanPlan struct {
+	codec         *geometryFormatCodec
+	bytesScanPlan pgtype.ScanPlan
+}
+
+// PlanEncode implements [github.com/jackc/pgx/v5/pgtype.Codec.PlanEncode].
+func (c *geometryFormatCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	if _, ok := geomFromValue(value); !ok {
+		return c.Codec.PlanEncode(m, oid, format, value)
+	}
+	var encodePlan pgtype.EncodePlan
+	switch c.format {
+	case geometryFormatWKB:
+		encodePlan = c.Codec.PlanEncode(m, oid, format, []byte(nil))
+	default:
+		encodePlan = c.Codec.PlanEncode(m, oid, format, "")
+	}
+	if encodePlan == nil {
+		return nil
+	}
+	return geometryFormatEncodePlan{
+		codec:      c,
+		encodePlan: encodePlan,
+	}
+}
+
+// PlanScan implements [github.com/jackc/pgx/v5/pgtype.Codec.PlanScan].
+func (c *geometryFormatCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	switch target.(type) {
+	case **geos.Geom, *Geom, *NullGeom, *Geography:
+		bytesScanPlan := c.Codec.PlanScan(m, oid, format, &[]byte{})
+		if bytesScanPlan == nil {
+			return nil
+		}
+		return geometryFormatScanPlan{
+			codec:         c,
+			bytesScanPlan: bytesScanPlan,
+		}
+	default:
+		return c.Codec.PlanScan(m, oid, format, target)
+	}
+}
+
+// Encode implements [github.com/jackc/pgx/v5/pgtype.EncodePlan.Encode].
+func (p geometryFormatEncodePlan) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	geom, ok := geomFromValue(value)
+	if !ok {
+		return buf, errors.ErrUnsupported
+	}
+	if geom == nil {
+		return nil, nil
+	}
+	data, err := p.codec.encode(geom)
+	if err != nil {
+		return buf, err
+	}
+	return p.encodePlan.Encode(data, buf)
+}
+
+// Scan implements [github.com/jackc/pgx/v5/pgtype.ScanPlan.Scan].
+func (p geometryFormatScanPlan) Scan(src []byte, target any) error {
+	if src == nil {
+		return scanGeom(target, nil)
+	}
+	var data []byte
+	if err := p.bytesScanPlan.Scan(src, &data); err != nil {
+		return err
+	}
+	geom, err := p.codec.decode(data)
+	if err != nil {
+		return err
+	}
+	return scanGeom(target, geom)
+}
+
+// decode returns a new geometry parsed from data in c's format.
+func (c *geometryFormatCodec) decode(data []byte) (*geos.Geom, error) {
+	switch c.format {
+	case geometryFormatWKB:
+		return c.geometryCodec.decodeEWKB(data)
+	case geometryFormatGeoJSON:
+		return c.decodeGeoJSON(data)
+	default:
+		data = bytes.TrimSpace(data)
+		switch {
+		case len(data) > 0 && data[0] == '{':
+			return c.decodeGeoJSON(data)
+		case isHexEWKB(data):
+			scratch := getScratchBuffer()
+			defer putScratchBuffer(scratch)
+			ewkb, err := hexDecodeScratch(scratch, data)
+			if err != nil {
+				return nil, err
+			}
+			return c.geometryCodec.decodeEWKB(ewkb)
+		default:
+			geom, err := newGeomFromEWKT(c.geometryCodec.geosContexts.Context(), string(data))
+			if err != nil {
+				return nil, err
+			}
+			return c.geometryCodec.decodeGeom(geom)
+		}
+	}
+}
+
+// encode returns geom in c's format, as EWKB []byte for bytea, EWKT string
+// for text and varchar, and GeoJSON string for json and jsonb. Geometries with
+// an SRID are intended for ST_GeomFromEWKB and ST_GeomFromEWKT:
+// ST_GeomFromWKB and ST_GeomFromText keep the SRID but emit a WARNING.
+func (c *geometryFormatCodec) encode(geom *geos.Geom) (any, error) {
+	switch c.format {
+	case geometryFormatWKB:
+		return c.geometryCodec.encodeEWKB(nil, geom)
+	case geometryFormatGeoJSON:
+		geom, err := validateGeom(c.geometryCodec.validation, geom)
+		if err != nil {
+			return nil, err
+		}
+		return geom.ToGeoJSON(0), nil
+	default:
+		geom, err := validateGeom(c.geometryCodec.validation, geom)
+		if err != nil {
+			return nil, err
+		}
+		srid := geom.SRID()
+		if srid == 0 {
+			srid = c.geometryCodec.defaultSRID
+		}
+		if srid == 0 {
+			return geom.ToWKT(), nil
+		}
+		return "SRID=" + strconv.Itoa(srid) + ";" + geom.ToWKT(), nil
+	}
+}
+
+// decodeGeoJSON returns a new geometry parsed from the GeoJSON geometry
+// geoJSON.
+func (c *geometryFormatCodec) decodeGeoJSON(geoJSON []byte) (*geos.Geom, error) {
+	geom, err := c.geometryCodec.geosContexts.Context().NewGeomFromGeoJSON(string(geoJSON))
+	if err != nil {
+		return nil, err
+	}
+	return c.geometryCodec.decodeGeom(geom)
+}
+
+// isHexEWKB returns if data looks like hex-encoded EWKB, that is, it starts
+// with a hex-encoded byte order of 00 or 01 and contains only hex digits.
+func isHexEWKB(data []byte) bool {
+	if len(data) < 10 || len(data)%2 != 0 || data[0] != '0' || data[1] != '0' && data[1] != '1' {
+		return false
+	}
+	for _, b := range data {
+		switch {
+		case '0' <= b && b <= '9':
+		case 'A' <= b && b <= 'F':
+		case 'a' <= b && b <= 'f':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newGeomFromEWKT returns a new geometry parsed from ewkt, which may be WKT or
+// WKT with an SRID=srid; prefix.
+func newGeomFromEWKT(geosContext *geos.Context, ewkt string) (*geos.Geom, error) {
+	var srid int
+	if prefix, wkt, ok := strings.Cut(ewkt, ";"); ok && len(prefix) > 5 && strings.EqualFold(prefix[:5], "SRID=") {
+		var err error
+		if srid, err = strconv.Atoi(prefix[5:]); err != nil {
+			return nil, fmt.Errorf("%s: invalid SRID: %w", prefix, err)
+		}
+		ewkt = wkt
+	}
+	geom, err := geosContext.NewGeomFromWKT(ewkt)
+	if err != nil {
+		return nil, err
+	}
+	if srid != 0 {
+		geom.SetSRID(srid)
+	}
+	return geom, nil
+}
+
+// registerGeometryFormats wraps the codecs of the non-geometry types
+// registered on m so that they can also scan and encode geometries, using
+// codec to decode and encode them.
+func registerGeometryFormats(m *pgtype.Map, codec *geometryCodec) {
+	for _, geometryFormatType := range geometryFormatTypes {
+		t, ok := m.TypeForName(geometryFormatType.name)
+		if !ok {
+			continue
+		}
+		innerCodec := t.Codec
+		if wrappedCodec, ok := innerCodec.(*geometryFormatCodec); ok {
+			innerCodec = wrappedCodec.Codec
+		}
+		m.RegisterType(&pgtype.Type{
+			Codec: &geometryFormatCodec{
+				Codec:         innerCodec,
+				geometryCodec: codec,
+				format:        geometryFormatType.format,
+			},
+			Name: t.Name,
+			OID:  t.OID,
+		})
+	}
+}
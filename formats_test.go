@@ -0,0 +1,145 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestGeometryFormatsScan(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, tc := range []struct {
+			name         string
+			expr         string
+			expectedSRID int
+		}{
+			{name: "st_asbinary", expr: "ST_AsBinary($1::geometry)"},
+			{name: "st_asewkb", expr: "ST_AsEWKB($1::geometry)", expectedSRID: 4326},
+			{name: "st_astext", expr: "ST_AsText($1::geometry)"},
+			{name: "st_asewkt", expr: "ST_AsEWKT($1::geometry)", expectedSRID: 4326},
+			{name: "st_asewkt_varchar", expr: "ST_AsEWKT($1::geometry)::varchar", expectedSRID: 4326},
+			{name: "text", expr: "$1::geometry::text", expectedSRID: 4326},
+			{name: "st_asgeojson", expr: "ST_AsGeoJSON($1::geometry)"},
+			{name: "st_asgeojson_json", expr: "ST_AsGeoJSON($1::geometry)::json"},
+			{name: "st_asgeojson_jsonb", expr: "ST_AsGeoJSON($1::geometry)::jsonb"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				for _, format := range []int16{
+					pgx.BinaryFormatCode,
+					pgx.TextFormatCode,
+				} {
+					t.Run(strconv.Itoa(int(format)), func(t *testing.T) {
+						geom := mustNewGeomFromWKT(t, "LINESTRING(1 2,3 4)").SetSRID(4326)
+						var actual *geos.Geom
+						assert.NoError(t, conn.QueryRow(ctx, "select "+tc.expr, pgx.QueryResultFormats{format}, geom).Scan(&actual))
+						expected := mustNewGeomFromWKT(t, "LINESTRING(1 2,3 4)").SetSRID(tc.expectedSRID)
+						assert.Equal(t, expected.ToEWKBWithSRID(), actual.ToEWKBWithSRID())
+
+						var actualNull *geos.Geom
+						assert.NoError(t, conn.QueryRow(ctx, "select "+tc.expr, pgx.QueryResultFormats{format}, nil).Scan(&actualNull))
+						assert.Zero(t, actualNull)
+					})
+				}
+			})
+		}
+	})
+}
+
+func TestGeometryFormatsScanUnchanged(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		var actualString string
+		assert.NoError(tb, conn.QueryRow(ctx, "select 'POINT(1 2)'::text").Scan(&actualString))
+		assert.Equal(tb, "POINT(1 2)", actualString)
+
+		var actualBytes []byte
+		assert.NoError(tb, conn.QueryRow(ctx, `select '\x0102'::bytea`).Scan(&actualBytes))
+		assert.Equal(tb, []byte{1, 2}, actualBytes)
+
+		var actualMap map[string]any
+		assert.NoError(tb, conn.QueryRow(ctx, `select '{"a":1}'::jsonb`).Scan(&actualMap))
+		assert.Equal(tb, map[string]any{"a": float64(1)}, actualMap)
+	})
+}
+
+func TestGeometryFormatsEncode(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, tc := range []struct {
+			name         string
+			expr         string
+			expectedSRID int
+		}{
+			// ST_GeomFromText and ST_GeomFromWKB keep the SRID of EWKT and
+			// EWKB, but emit a WARNING.
+			{name: "st_geomfromtext", expr: "ST_GeomFromText($1)", expectedSRID: 4326},
+			{name: "st_geomfromewkt", expr: "ST_GeomFromEWKT($1)", expectedSRID: 4326},
+			{name: "st_geomfromewkt_varchar", expr: "ST_GeomFromEWKT($1::varchar)", expectedSRID: 4326},
+			{name: "st_geomfromwkb", expr: "ST_GeomFromWKB($1)", expectedSRID: 4326},
+			{name: "st_geomfromewkb", expr: "ST_GeomFromEWKB($1)", expectedSRID: 4326},
+			// GeoJSON has no SRID, so the SRID depends on the PostGIS version.
+			{name: "st_geomfromgeojson_json", expr: "ST_GeomFromGeoJSON($1::json)"},
+			{name: "st_geomfromgeojson_jsonb", expr: "ST_GeomFromGeoJSON($1::jsonb)"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				geom := mustNewGeomFromWKT(t, "LINESTRING(1 2,3 4)").SetSRID(4326)
+				var actualWKT string
+				var actualSRID int
+				assert.NoError(t, conn.QueryRow(ctx, "select ST_AsText("+tc.expr+"), ST_SRID("+tc.expr+")", geom).Scan(&actualWKT, &actualSRID))
+				assert.Equal(t, "LINESTRING(1 2,3 4)", actualWKT)
+				if tc.expectedSRID != 0 {
+					assert.Equal(t, tc.expectedSRID, actualSRID)
+				}
+
+				var nullGeom *geos.Geom
+				var actualNull *string
+				assert.NoError(t, conn.QueryRow(ctx, "select ST_AsText("+tc.expr+")", nullGeom).Scan(&actualNull))
+				assert.Zero(t, actualNull)
+			})
+		}
+	})
+}
+
+func TestGeometryFormatsRegistration(t *testing.T) {
+	oids := pgxgeos.OIDs{
+		Box2D:    pgxgeos.TypeOIDs{OID: 100001},
+		Geometry: pgxgeos.TypeOIDs{OID: geometryTestOID},
+	}
+	for _, tc := range []struct {
+		name            string
+		types           pgxgeos.Type
+		expectedWrapped bool
+	}{
+		{
+			name:            "all",
+			types:           pgxgeos.AllTypes,
+			expectedWrapped: true,
+		},
+		{
+			name:  "box2d",
+			types: pgxgeos.TypeBox2D,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := pgtype.NewMap()
+			_, err := pgxgeos.RegisterTypeMap(m, oids, pgxgeos.WithTypes(tc.types))
+			assert.NoError(t, err)
+			for _, name := range []string{"bytea", "json", "jsonb", "text", "varchar"} {
+				expected, ok := pgtype.NewMap().TypeForName(name)
+				assert.True(t, ok)
+				actual, ok := m.TypeForName(name)
+				assert.True(t, ok)
+				assert.Equal(t, !tc.expectedWrapped, reflect.TypeOf(expected.Codec) == reflect.TypeOf(actual.Codec), name)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package pgxgeos
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/twpayne/go-geos"
+)
+
+// geographyDefaultSRID is the SRID of geography values with no SRID, as in
+// PostGIS.
+const geographyDefaultSRID = 4326
+
+// A Geography wraps a [*github.com/twpayne/go-geos.Geom] that is a PostGIS
+// geography. Geography values are scanned from and encoded by the codec
+// registered for geography, which validates them on encode: geometries with
+// no SRID are given SRID 4326, geometries with a non-geodetic SRID are
+// rejected, and geometries with longitudes or latitudes out of range are
+// rejected. Geodetic SRIDs are those of the longitude/latitude coordinate
+// reference systems in spatial_ref_sys, 4326, and any added with
+// [WithGeodeticSRIDs]. Geography implements [database/sql.Scanner] and
+// [database/sql/driver.Valuer], but these do not validate.
+//
+// Geography cannot scan NULL values.
+type Geography struct {
+	*geos.Geom
+}
+
+// Scan implements [database/sql.Scanner.Scan]. src may be EWKB or hex-encoded
+// EWKB.
+func (g *Geography) Scan(src any) error {
+	geom, err := geomFromSQLValue(src)
+	if err != nil {
+		return err
+	}
+	if geom == nil {
+		return fmt.Errorf("%T: %w", g, errScanNull)
+	}
+	g.Geom = geom
+	return nil
+}
+
+// Value implements [database/sql/driver.Valuer.Value]. It returns hex-encoded
+// EWKB, or nil if g is nil.
+func (g Geography) Value() (driver.Value, error) {
+	if g.Geom == nil {
+		return nil, nil
+	}
+	return hex.EncodeToString(g.ToEWKBWithSRID()), nil
+}
+
+// validateGeography returns an error wrapping [ErrInvalidGeography] if geom
+// is not a valid geography. A zero SRID is treated as defaultSRID.
+func validateGeography(geom *geos.Geom, defaultSRID int, geodeticSRIDs map[int]struct{}) error {
+	var bounds *geos.Box2D
+	if !geom.IsEmpty() {
+		bounds = geom.Bounds()
+	}
+	return checkGeography(geom.SRID(), bounds, defaultSRID, geodeticSRIDs)
+}
+
+// validateGeographyEWKB returns an error wrapping [ErrInvalidGeography] if
+// ewkb is not a valid geography, without using GEOS. A zero SRID is treated as
+// defaultSRID.
+func validateGeographyEWKB(ewkb []byte, defaultSRID int, geodeticSRIDs map[int]struct{}) error {
+	header, err := parseEWKBHeader(ewkb)
+	if err != nil {
+		return err
+	}
+	envelope, err := ewkbEnvelope(ewkb)
+	if err != nil {
+		return err
+	}
+	var bounds *geos.Box2D
+	if !math.IsInf(envelope.MinX, 1) {
+		bounds = box2DFromBox3D(envelope)
+	}
+	return checkGeography(header.srid, bounds, defaultSRID, geodeticSRIDs)
+}
+
+// checkGeography returns an error wrapping [ErrInvalidGeography] if srid is
+// not geodetic or bounds, which is nil if the geography is empty, are out of
+// range. A zero SRID is treated as defaultSRID.
+func checkGeography(srid int, bounds *geos.Box2D, defaultSRID int, geodeticSRIDs map[int]struct{}) error {
+	if srid == 0 {
+		srid = defaultSRID
+	}
+	if _, ok := geodeticSRIDs[srid]; !ok {
+		return fmt.Errorf("SRID %d is not geodetic: %w", srid, ErrInvalidGeography)
+	}
+	if bounds == nil {
+		return nil
+	}
+	if bounds.MinX < -180 || bounds.MaxX > 180 || bounds.MinY < -90 || bounds.MaxY > 90 {
+		return fmt.Errorf("%s: coordinates out of range: %w", bounds, ErrInvalidGeography)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestGeographyCodec(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, format := range []int16{
+			pgx.BinaryFormatCode,
+			pgx.TextFormatCode,
+		} {
+			tb.(*testing.T).Run(strconv.Itoa(int(format)), func(t *testing.T) { //nolint:forcetypeassert
+				geography := pgxgeos.Geography{Geom: mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(4326)}
+				var actual pgxgeos.Geography
+				assert.NoError(t, conn.QueryRow(ctx, "select $1::geography", pgx.QueryResultFormats{format}, geography).Scan(&actual))
+				assert.Equal(t, geography.ToEWKBWithSRID(), actual.ToEWKBWithSRID())
+
+				var actualSRID int
+				assert.NoError(t, conn.QueryRow(ctx, "select ST_SRID($1::geography)", pgx.QueryResultFormats{format}, pgxgeos.Geography{Geom: mustNewGeomFromWKT(t, "POINT(1 2)")}).Scan(&actualSRID))
+				assert.Equal(t, 4326, actualSRID)
+
+				for _, tc := range []struct {
+					name        string
+					geom        *geos.Geom
+					expectedErr string
+				}{
+					{
+						name:        "non_geodetic_srid",
+						geom:        mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(3857),
+						expectedErr: "SRID 3857 is not geodetic: invalid geography",
+					},
+					{
+						name:        "longitude_out_of_range",
+						geom:        mustNewGeomFromWKT(t, "POINT(181 2)").SetSRID(4326),
+						expectedErr: "[181.000000 2.000000 181.000000 2.000000]: coordinates out of range: invalid geography",
+					},
+					{
+						name:        "latitude_out_of_range",
+						geom:        mustNewGeomFromWKT(t, "LINESTRING(0 0,1 91)").SetSRID(4326),
+						expectedErr: "[0.000000 0.000000 1.000000 91.000000]: coordinates out of range: invalid geography",
+					},
+				} {
+					t.Run(tc.name, func(t *testing.T) {
+						err := conn.QueryRow(ctx, "select $1::geography", pgx.QueryResultFormats{format}, pgxgeos.Geography{Geom: tc.geom}).Scan(&actual)
+						assert.IsError(t, err, pgxgeos.ErrInvalidGeography)
+						assert.Contains(t, err.Error(), tc.expectedErr)
+					})
+				}
+			})
+		}
+	})
+}
+
+func TestGeographyCodecGeodeticSRIDs(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, srid := range []int{4152, 4230, 4267} {
+			geography := pgxgeos.Geography{Geom: mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(srid)}
+			var actual pgxgeos.Geography
+			assert.NoError(tb, conn.QueryRow(ctx, "select $1::geography", geography).Scan(&actual))
+			assert.Equal(tb, geography.ToEWKBWithSRID(), actual.ToEWKBWithSRID())
+		}
+	})
+}
+
+func TestGeographyEncodeEWKB(t *testing.T) {
+	const geographyTestOID = 100004
+	for _, tc := range []struct {
+		name         string
+		opts         []pgxgeos.Option
+		value        any
+		expectedSRID int
+		expectedErr  string
+	}{
+		{
+			name:         "default_srid",
+			value:        pgxgeos.EWKB(pgxgeos.NewEWKBWriter(0).AppendPointXY(nil, 1, 2)),
+			expectedSRID: 4326,
+		},
+		{
+			name:         "empty",
+			value:        mustEWKB(pgxgeos.NewEWKBWriter(4326).Point(nil)),
+			expectedSRID: 4326,
+		},
+		{
+			name:         "nad27",
+			value:        pgxgeos.EWKB(pgxgeos.NewEWKBWriter(4267).AppendPointXY(nil, 1, 2)),
+			expectedSRID: 4267,
+		},
+		{
+			name:         "with_geodetic_srids",
+			opts:         []pgxgeos.Option{pgxgeos.WithGeodeticSRIDs(3857)},
+			value:        pgxgeos.EWKB(pgxgeos.NewEWKBWriter(3857).AppendPointXY(nil, 1, 2)),
+			expectedSRID: 3857,
+		},
+		{
+			name:        "ny_state_plane_srid",
+			value:       pgxgeos.EWKB(pgxgeos.NewEWKBWriter(2263).AppendPointXY(nil, 1, 2)),
+			expectedErr: "SRID 2263 is not geodetic: invalid geography",
+		},
+		{
+			name:        "utm_srid",
+			value:       pgxgeos.EWKB(pgxgeos.NewEWKBWriter(32631).AppendPointXY(nil, 1, 2)),
+			expectedErr: "SRID 32631 is not geodetic: invalid geography",
+		},
+		{
+			name:        "non_geodetic_srid",
+			value:       pgxgeos.EWKB(pgxgeos.NewEWKBWriter(3857).AppendPointXY(nil, 1, 2)),
+			expectedErr: "SRID 3857 is not geodetic: invalid geography",
+		},
+		{
+			name:        "longitude_out_of_range",
+			value:       pgxgeos.EWKB(pgxgeos.NewEWKBWriter(4326).AppendPointXY(nil, 181, 2)),
+			expectedErr: "[181.000000 2.000000 181.000000 2.000000]: coordinates out of range: invalid geography",
+		},
+		{
+			name:        "box_out_of_range",
+			value:       geos.NewBox2D(0, 0, 1, 91),
+			expectedErr: "[0.000000 0.000000 1.000000 91.000000]: coordinates out of range: invalid geography",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := pgtype.NewMap()
+			_, err := pgxgeos.RegisterTypeMap(m, pgxgeos.OIDs{
+				Geography:     pgxgeos.TypeOIDs{OID: geographyTestOID},
+				GeodeticSRIDs: []int{4267, 4326},
+			}, append([]pgxgeos.Option{pgxgeos.WithGEOSContext(geos.DefaultContext)}, tc.opts...)...)
+			assert.NoError(t, err)
+			ewkb, err := m.Encode(geographyTestOID, pgx.BinaryFormatCode, tc.value, nil)
+			if tc.expectedErr != "" {
+				assert.IsError(t, err, pgxgeos.ErrInvalidGeography)
+				assert.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			header, err := pgxgeos.InspectEWKB(ewkb)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedSRID, header.SRID)
+		})
+	}
+}
+
+func mustEWKB(ewkb pgxgeos.EWKB, err error) pgxgeos.EWKB {
+	if err != nil {
+		panic(err)
+	}
+	return ewkb
+}
+
+func TestNewGeographyCodec(t *testing.T) {
+	const geographyTestOID = 100004
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Name: "geography", OID: geographyTestOID, Codec: pgxgeos.NewGeographyCodec(geos.DefaultContext)})
+
+	ewkb, err := m.Encode(geographyTestOID, pgx.BinaryFormatCode, pgxgeos.EWKB(pgxgeos.NewEWKBWriter(0).AppendPointXY(nil, 1, 2)), nil)
+	assert.NoError(t, err)
+	header, err := pgxgeos.InspectEWKB(ewkb)
+	assert.NoError(t, err)
+	assert.Equal(t, 4326, header.SRID)
+
+	_, err = m.Encode(geographyTestOID, pgx.BinaryFormatCode, pgxgeos.EWKB(pgxgeos.NewEWKBWriter(3857).AppendPointXY(nil, 1, 2)), nil)
+	assert.IsError(t, err, pgxgeos.ErrInvalidGeography)
+}
@@ -0,0 +1,177 @@
+package pgxgeos
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ewkbToGeoJSON returns the GeoJSON geometry of ewkb, without using GEOS. Z
+// ordinates are included, M ordinates and the SRID are not.
+func ewkbToGeoJSON(ewkb []byte) ([]byte, error) {
+	geoJSON, rest, err := appendEWKBGeoJSON(make([]byte, 0, 2*len(ewkb)), ewkb)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%d trailing bytes: %w", len(rest), ErrInvalidEWKB)
+	}
+	return geoJSON, nil
+}
+
+// appendEWKBGeoJSON appends the geometry at the start of ewkb to dst as a
+// GeoJSON geometry and returns the remainder of ewkb.
+func appendEWKBGeoJSON(dst, ewkb []byte) ([]byte, []byte, error) {
+	header, err := parseEWKBHeader(ewkb)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch header.geometryType {
+	case GeometryTypePoint, GeometryTypeLineString, GeometryTypePolygon,
+		GeometryTypeMultiPoint, GeometryTypeMultiLineString, GeometryTypeMultiPolygon:
+		dst = append(dst, `{"type":"`...)
+		dst = append(dst, header.geometryType.String()...)
+		dst = append(dst, `","coordinates":`...)
+		if dst, ewkb, err = appendEWKBGeoJSONCoordinates(dst, ewkb); err != nil {
+			return nil, nil, err
+		}
+		return append(dst, '}'), ewkb, nil
+	case GeometryTypeGeometryCollection:
+		ewkb = ewkb[header.size:]
+		var n uint32
+		if n, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+			return nil, nil, err
+		}
+		dst = append(dst, `{"type":"GeometryCollection","geometries":[`...)
+		for i := range n {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			if dst, ewkb, err = appendEWKBGeoJSON(dst, ewkb); err != nil {
+				return nil, nil, err
+			}
+		}
+		return append(dst, "]}"...), ewkb, nil
+	default:
+		return nil, nil, fmt.Errorf("%s: unsupported GeoJSON geometry type: %w", header.geometryType, ErrInvalidEWKB)
+	}
+}
+
+// appendEWKBGeoJSONCoordinates appends the coordinates of the geometry at the
+// start of ewkb to dst as GeoJSON coordinates and returns the remainder of
+// ewkb.
+func appendEWKBGeoJSONCoordinates(dst, ewkb []byte) ([]byte, []byte, error) {
+	header, err := parseEWKBHeader(ewkb)
+	if err != nil {
+		return nil, nil, err
+	}
+	ewkb = ewkb[header.size:]
+	var n uint32
+	switch header.geometryType {
+	case GeometryTypePoint:
+		size := 8 * header.dimensions()
+		if len(ewkb) < size {
+			return nil, nil, fmt.Errorf("short coordinates: %w", ErrInvalidEWKB)
+		}
+		if math.IsNaN(readFloat64(ewkb, header.byteOrder)) && math.IsNaN(readFloat64(ewkb[8:], header.byteOrder)) {
+			return append(dst, "[]"...), ewkb[size:], nil
+		}
+		return appendGeoJSONPositions(dst, ewkb, 1, header, false)
+	case GeometryTypeLineString:
+		if n, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+			return nil, nil, err
+		}
+		return appendGeoJSONPositions(dst, ewkb, n, header, true)
+	case GeometryTypePolygon:
+		if n, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+			return nil, nil, err
+		}
+		dst = append(dst, '[')
+		for i := range n {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			var points uint32
+			if points, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+				return nil, nil, err
+			}
+			if dst, ewkb, err = appendGeoJSONPositions(dst, ewkb, points, header, true); err != nil {
+				return nil, nil, err
+			}
+		}
+		return append(dst, ']'), ewkb, nil
+	case GeometryTypeMultiPoint, GeometryTypeMultiLineString, GeometryTypeMultiPolygon:
+		if n, ewkb, err = readUint32(ewkb, header.byteOrder); err != nil {
+			return nil, nil, err
+		}
+		dst = append(dst, '[')
+		for i := range n {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			if dst, ewkb, err = appendEWKBGeoJSONCoordinates(dst, ewkb); err != nil {
+				return nil, nil, err
+			}
+		}
+		return append(dst, ']'), ewkb, nil
+	default:
+		return nil, nil, fmt.Errorf("%s: unsupported GeoJSON geometry type: %w", header.geometryType, ErrInvalidEWKB)
+	}
+}
+
+// appendGeoJSONPositions appends n coordinates from ewkb, which have the
+// ordinates of header, to dst as GeoJSON positions, enclosed in an array if
+// array is set, and returns the remainder of ewkb.
+func appendGeoJSONPositions(dst, ewkb []byte, n uint32, header ewkbHeader, array bool) ([]byte, []byte, error) {
+	size := 8 * header.dimensions()
+	if uint64(len(ewkb)) < uint64(n)*uint64(size) {
+		return nil, nil, fmt.Errorf("short coordinates: %w", ErrInvalidEWKB)
+	}
+	if array {
+		dst = append(dst, '[')
+	}
+	for i := range n {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		ordinates := 2
+		if header.z {
+			ordinates++
+		}
+		dst = append(dst, '[')
+		for j := range ordinates {
+			if j > 0 {
+				dst = append(dst, ',')
+			}
+			f := readFloat64(ewkb[8*j:], header.byteOrder)
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return nil, nil, fmt.Errorf("%f: invalid ordinate: %w", f, ErrInvalidEWKB)
+			}
+			dst = appendGeoJSONFloat(dst, f)
+		}
+		dst = append(dst, ']')
+		ewkb = ewkb[size:]
+	}
+	if array {
+		dst = append(dst, ']')
+	}
+	return dst, ewkb, nil
+}
+
+// appendGeoJSONFloat appends f to dst in the same format as
+// [encoding/json.Marshal].
+func appendGeoJSONFloat(dst []byte, f float64) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+		// Clean up e-09 to e-9, as encoding/json does.
+		if n := len(dst); n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
@@ -1,10 +1,13 @@
 package pgxgeos
 
 import (
-	"context"
+	"bytes"
 	"database/sql/driver"
+	"encoding"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -12,31 +15,48 @@ import (
 )
 
 // A geometryCodec implements [github.com/jackc/pgx/v5/pgtype.Codec] for
-// [*github.com/twpayne/go-geos.Geom] types.
+// [*github.com/twpayne/go-geos.Geom] types. If geography is set then it
+// validates geometries as geographies and decodes values as [Geography]s.
+//
+// If typmod is set then geometries are checked against it and coerced with
+// coercions when they are encoded.
+//
+// Geometries are created with contexts from geosContexts.
 type geometryCodec struct {
-	geosContext *geos.Context
+	geosContexts  ContextPool
+	defaultSRID   int
+	geography     bool
+	geodeticSRIDs map[int]struct{}
+	typmod        *GeometryTypmod
+	coercions     Coercion
+	validation    ValidationPolicy
+	scanHooks     []ScanHook
 }
 
 // A geometryBinaryEncodePlan implements
 // [github.com/jackc/pgx/v5/pgtype.EncodePlan] for
 // [*github.com/twpayne/go-geos.Geom] types in binary format.
-type geometryBinaryEncodePlan struct{}
+type geometryBinaryEncodePlan struct {
+	codec *geometryCodec
+}
 
 // A geometryTextEncodePlan implements
 // [github.com/jackc/pgx/v5/pgtype.EncodePlan] for
 // [*github.com/twpayne/go-geos.Geom] types in text format.
-type geometryTextEncodePlan struct{}
+type geometryTextEncodePlan struct {
+	codec *geometryCodec
+}
 
 // A geometryBinaryScanPlan implements [github.com/jackc/pgx/v5/pgtype.ScanPlan]
 // for [*github.com/twpayne/go-geos.Geom] types in binary format.
 type geometryBinaryScanPlan struct {
-	geosContext *geos.Context
+	codec *geometryCodec
 }
 
 // A geometryTextScanPlan implements [github.com/jackc/pgx/v5/pgtype.ScanPlan]
 // for [*github.com/twpayne/go-geos.Geom] types in text format.
 type geometryTextScanPlan struct {
-	geosContext *geos.Context
+	codec *geometryCodec
 }
 
 // FormatSupported implements
@@ -60,14 +80,22 @@ func (c *geometryCodec) PreferredFormat() int16 {
 
 // PlanEncode implements [github.com/jackc/pgx/v5/pgtype.Codec.PlanEncode].
 func (c *geometryCodec) PlanEncode(m *pgtype.Map, old uint32, format int16, value any) pgtype.EncodePlan {
-	if _, ok := value.(*geos.Geom); !ok {
-		return nil
+	switch value.(type) {
+	case EWKBMarshaler, encoding.BinaryMarshaler, geos.Box2D, *geos.Box2D, geos.Box3D, *geos.Box3D:
+	default:
+		if _, ok := geomFromValue(value); !ok {
+			return nil
+		}
 	}
 	switch format {
 	case pgtype.BinaryFormatCode:
-		return geometryBinaryEncodePlan{}
+		return geometryBinaryEncodePlan{
+			codec: c,
+		}
 	case pgtype.TextFormatCode:
-		return geometryTextEncodePlan{}
+		return geometryTextEncodePlan{
+			codec: c,
+		}
 	default:
 		return nil
 	}
@@ -75,17 +103,19 @@ func (c *geometryCodec) PlanEncode(m *pgtype.Map, old uint32, format int16, valu
 
 // PlanScan implements [github.com/jackc/pgx/v5/pgtype.Codec.PlanScan].
 func (c *geometryCodec) PlanScan(m *pgtype.Map, old uint32, format int16, target any) pgtype.ScanPlan {
-	if _, ok := target.(**geos.Geom); !ok {
+	switch target.(type) {
+	case **geos.Geom, *Geom, *NullGeom, *Geography, *LazyGeom, EWKBUnmarshaler, *[]byte, *string, *json.RawMessage, *geos.Box2D, *geos.Box3D:
+	default:
 		return nil
 	}
 	switch format {
 	case pgx.BinaryFormatCode:
 		return geometryBinaryScanPlan{
-			geosContext: c.geosContext,
+			codec: c,
 		}
 	case pgx.TextFormatCode:
 		return geometryTextScanPlan{
-			geosContext: c.geosContext,
+			codec: c,
 		}
 	default:
 		return nil
@@ -94,23 +124,40 @@ func (c *geometryCodec) PlanScan(m *pgtype.Map, old uint32, format int16, target
 
 // DecodeDatabaseSQLValue implements
 // [github.com/jackc/pgx/v5/pgtype.Codec.DecodeDatabaseSQLValue].
+//
+// It returns the geometry in EWKB format as a []byte.
 func (c *geometryCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
-	return nil, errors.ErrUnsupported
+	if src == nil {
+		return nil, nil
+	}
+	switch format {
+	case pgtype.BinaryFormatCode:
+		return bytes.Clone(src), nil
+	case pgtype.TextFormatCode:
+		return hex.AppendDecode(make([]byte, 0, hex.DecodedLen(len(src))), src)
+	default:
+		return nil, errors.ErrUnsupported
+	}
 }
 
 // DecodeValue implements [github.com/jackc/pgx/v5/pgtype.Codec.DecodeValue].
 func (c *geometryCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
 	switch format {
 	case pgtype.TextFormatCode:
+		scratch := getScratchBuffer()
+		defer putScratchBuffer(scratch)
 		var err error
-		src, err = hex.DecodeString(string(src))
+		src, err = hexDecodeScratch(scratch, src)
 		if err != nil {
 			return nil, err
 		}
 		fallthrough
 	case pgtype.BinaryFormatCode:
-		geom, err := c.geosContext.NewGeomFromWKB(src)
-		return geom, err
+		geom, err := c.decodeEWKB(src)
+		if err != nil {
+			return nil, err
+		}
+		return c.value(geom), nil
 	default:
 		return nil, errors.ErrUnsupported
 	}
@@ -118,91 +165,330 @@ func (c *geometryCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src
 
 // Encode implements [github.com/jackc/pgx/v5/pgtype.EncodePlan.Encode].
 func (p geometryBinaryEncodePlan) Encode(value any, buf []byte) (newBuf []byte, err error) {
-	geom, ok := value.(*geos.Geom)
-	if !ok {
-		return buf, errors.ErrUnsupported
-	}
-	return append(buf, geom.ToEWKBWithSRID()...), nil
+	return p.codec.encode(buf, value)
 }
 
 // Encode implements [github.com/jackc/pgx/v5/pgtype.EncodePlan.Encode].
 func (p geometryTextEncodePlan) Encode(value any, buf []byte) (newBuf []byte, err error) {
-	geom, ok := value.(*geos.Geom)
-	if !ok {
-		return buf, errors.ErrUnsupported
+	scratch := getScratchBuffer()
+	defer putScratchBuffer(scratch)
+	ewkb, err := p.codec.encode(*scratch, value)
+	if err != nil || ewkb == nil {
+		return nil, err
 	}
-	wkb := geom.ToEWKBWithSRID()
-	return append(buf, []byte(hex.EncodeToString(wkb))...), nil
+	*scratch = ewkb
+	return hex.AppendEncode(buf, ewkb), nil
 }
 
 // Scan implements [github.com/jackc/pgx/v5/pgtype.ScanPlan.Scan].
 func (p geometryBinaryScanPlan) Scan(src []byte, target any) error {
-	pgeom, ok := target.(**geos.Geom)
-	if !ok {
-		return errors.ErrUnsupported
+	if ok, err := p.codec.scanRawEWKB(target, src); ok {
+		return err
 	}
 	if len(src) == 0 {
-		*pgeom = nil
-		return nil
+		return scanGeom(target, nil)
 	}
-	geom, err := p.geosContext.NewGeomFromWKB(src)
+	geom, err := p.codec.decodeEWKB(src)
 	if err != nil {
 		return err
 	}
-	*pgeom = geom
-	return nil
+	return scanGeom(target, geom)
 }
 
 // Scan implements [github.com/jackc/pgx/v5/pgtype.ScanPlan.Scan].
 func (p geometryTextScanPlan) Scan(src []byte, target any) error {
-	pgeom, ok := target.(**geos.Geom)
-	if !ok {
-		return errors.ErrUnsupported
-	}
-	if len(src) == 0 {
-		*pgeom = nil
-		return nil
+	if src == nil {
+		if ok, err := p.codec.scanRawEWKB(target, nil); ok {
+			return err
+		}
+		return scanGeom(target, nil)
 	}
+	scratch := getScratchBuffer()
+	defer putScratchBuffer(scratch)
 	var err error
-	src, err = hex.DecodeString(string(src))
+	src, err = hexDecodeScratch(scratch, src)
 	if err != nil {
 		return err
 	}
-	geom, err := p.geosContext.NewGeomFromWKB(src)
+	if ok, err := p.codec.scanRawEWKB(target, src); ok {
+		return err
+	}
+	if len(src) == 0 {
+		return scanGeom(target, nil)
+	}
+	geom, err := p.codec.decodeEWKB(src)
 	if err != nil {
 		return err
 	}
-	*pgeom = geom
-	return nil
+	return scanGeom(target, geom)
 }
 
-// registerGeom registers codecs for [*github.com/twpayne/go-geos.Geom] types on conn.
-func registerGeom(ctx context.Context, conn *pgx.Conn, geosContext *geos.Context) error {
-	var geographyOID, geometryOID uint32
-	err := conn.QueryRow(ctx, "select 'geography'::text::regtype::oid, 'geometry'::text::regtype::oid").Scan(&geographyOID, &geometryOID)
+// encode appends value to buf in EWKB format, with c's validation policy,
+// default SRID, and typmod applied, or returns nil if value is NULL. Values
+// implementing [EWKBMarshaler] or [encoding.BinaryMarshaler] are not checked
+// against c's validation policy, but geographies are always checked.
+// [github.com/twpayne/go-geos.Box2D]s are encoded as rectangle polygons and
+// [github.com/twpayne/go-geos.Box3D]s as the faces of the box.
+func (c *geometryCodec) encode(buf []byte, value any) ([]byte, error) {
+	var ewkb []byte
+	var err error
+	switch value := value.(type) {
+	case EWKBMarshaler:
+		ewkb, err = value.MarshalEWKB()
+	case encoding.BinaryMarshaler:
+		ewkb, err = value.MarshalBinary()
+	case geos.Box2D:
+		ewkb, err = c.encodeBox2D(value)
+	case *geos.Box2D:
+		ewkb, err = c.encodeBox2D(*value)
+	case geos.Box3D:
+		ewkb, err = c.encodeBox3D(value)
+	case *geos.Box3D:
+		ewkb, err = c.encodeBox3D(*value)
+	default:
+		geom, ok := geomFromValue(value)
+		if !ok {
+			return nil, errors.ErrUnsupported
+		}
+		if geom == nil {
+			return nil, nil
+		}
+		return c.encodeEWKB(buf, geom)
+	}
+	if err != nil || ewkb == nil {
+		return nil, err
+	}
+	if c.geography {
+		if err := validateGeographyEWKB(ewkb, c.encodeDefaultSRID(), c.geodeticSRIDs); err != nil {
+			return nil, err
+		}
+	}
+	return c.finishEWKB(buf, ewkb)
+}
+
+// encodeBox2D returns box2D as a rectangle polygon in EWKB format, with c's
+// validation policy applied.
+func (c *geometryCodec) encodeBox2D(box2D geos.Box2D) ([]byte, error) {
+	if err := validateBox2D(c.validation, &box2D); err != nil {
+		return nil, err
+	}
+	return box2DToEWKB(&box2D), nil
+}
+
+// encodeBox3D returns box3D as its faces in EWKB format, with c's validation
+// policy applied.
+func (c *geometryCodec) encodeBox3D(box3D geos.Box3D) ([]byte, error) {
+	if err := validateBox3D(c.validation, &box3D); err != nil {
+		return nil, err
+	}
+	return box3DToEWKB(&box3D), nil
+}
+
+// scanRawEWKB sets target to ewkb without parsing it with GEOS, if target is
+// a [*LazyGeom], an [EWKBUnmarshaler], *[]byte, *string, which is set to
+// hex-encoded EWKB, *json.RawMessage, which is set to GeoJSON, or a
+// [*github.com/twpayne/go-geos.Box2D] or [*github.com/twpayne/go-geos.Box3D],
+// which is set to the envelope. ewkb is nil if the value is NULL. It returns
+// whether target was handled.
+//
+// In text format, pgx scans *[]byte and *string targets itself, setting them
+// to the hex-encoded EWKB, so they only reach scanRawEWKB in binary format.
+func (c *geometryCodec) scanRawEWKB(target any, ewkb []byte) (bool, error) {
+	switch target := target.(type) {
+	case *LazyGeom:
+		return true, target.set(c, ewkb)
+	case *geos.Box2D, *geos.Box3D:
+		if ewkb == nil {
+			return true, fmt.Errorf("%T: %w", target, errScanNull)
+		}
+		envelope, err := ewkbEnvelope(ewkb)
+		if err != nil {
+			return true, err
+		}
+		switch target := target.(type) {
+		case *geos.Box2D:
+			*target = geos.Box2D{
+				MinX: envelope.MinX,
+				MinY: envelope.MinY,
+				MaxX: envelope.MaxX,
+				MaxY: envelope.MaxY,
+			}
+		case *geos.Box3D:
+			*target = *envelope
+		}
+		return true, nil
+	case EWKBUnmarshaler:
+		return true, target.UnmarshalEWKB(ewkb)
+	case *[]byte:
+		*target = bytes.Clone(ewkb)
+		return true, nil
+	case *string:
+		if ewkb == nil {
+			return true, fmt.Errorf("%T: %w", target, errScanNull)
+		}
+		*target = hex.EncodeToString(ewkb)
+		return true, nil
+	case *json.RawMessage:
+		if ewkb == nil {
+			*target = nil
+			return true, nil
+		}
+		geoJSON, err := ewkbToGeoJSON(ewkb)
+		if err != nil {
+			return true, err
+		}
+		*target = geoJSON
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// decodeEWKB returns a new geometry parsed from ewkb, with c's validation
+// policy and scan hooks applied.
+func (c *geometryCodec) decodeEWKB(ewkb []byte) (*geos.Geom, error) {
+	return c.decodeEWKBWithContext(c.geosContexts.Context(), ewkb)
+}
+
+// decodeEWKBWithContext returns a new geometry created with geosContext from
+// ewkb, with c's validation policy and scan hooks applied.
+func (c *geometryCodec) decodeEWKBWithContext(geosContext *geos.Context, ewkb []byte) (*geos.Geom, error) {
+	geom, err := geosContext.NewGeomFromWKB(ewkb)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return c.decodeGeom(geom)
+}
 
-	if geosContext == nil {
-		geosContext = geos.DefaultContext
+// value returns geom as the value returned by DecodeValue.
+func (c *geometryCodec) value(geom *geos.Geom) any {
+	if c.geography {
+		return Geography{Geom: geom}
 	}
+	return geom
+}
 
-	conn.TypeMap().RegisterType(&pgtype.Type{
-		Codec: &geometryCodec{
-			geosContext: geosContext,
-		},
-		Name: "geography",
-		OID:  geographyOID,
-	})
+// decodeGeom returns geom with c's validation policy and scan hooks applied.
+func (c *geometryCodec) decodeGeom(geom *geos.Geom) (*geos.Geom, error) {
+	geom, err := validateGeom(c.validation, geom)
+	if err != nil {
+		return nil, err
+	}
+	if err := runScanHooks(c.scanHooks, geom); err != nil {
+		return nil, err
+	}
+	return geom, nil
+}
 
-	conn.TypeMap().RegisterType(&pgtype.Type{
-		Codec: &geometryCodec{
-			geosContext: geosContext,
-		},
-		Name: "geometry",
-		OID:  geometryOID,
-	})
+// encodeEWKB appends geom to buf in EWKB format, with c's validation policy,
+// default SRID, and typmod applied. Geographies with no default SRID default
+// to SRID 4326. GEOS cannot write into buf, so geom's EWKB is allocated and
+// then copied into buf.
+func (c *geometryCodec) encodeEWKB(buf []byte, geom *geos.Geom) ([]byte, error) {
+	geom, err := validateGeom(c.validation, geom)
+	if err != nil {
+		return nil, err
+	}
+	if c.geography {
+		if err := validateGeography(geom, c.encodeDefaultSRID(), c.geodeticSRIDs); err != nil {
+			return nil, err
+		}
+	}
+	return c.finishEWKB(buf, geom.ToEWKBWithSRID())
+}
 
-	return nil
+// finishEWKB appends ewkb to buf with c's default SRID and typmod applied.
+// Without a typmod, ewkb is written directly into buf.
+func (c *geometryCodec) finishEWKB(buf, ewkb []byte) ([]byte, error) {
+	defaultSRID := c.encodeDefaultSRID()
+	if c.typmod == nil {
+		if defaultSRID == 0 {
+			return append(buf, ewkb...), nil
+		}
+		return appendEWKBDefaultSRID(buf, ewkb, defaultSRID), nil
+	}
+	if defaultSRID != 0 {
+		ewkb = setEWKBDefaultSRID(ewkb, defaultSRID)
+	}
+	ewkb, err := c.typmod.apply(ewkb, c.coercions)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, ewkb...), nil
+}
+
+// encodeDefaultSRID returns the SRID given to geometries with no SRID when they
+// are encoded.
+func (c *geometryCodec) encodeDefaultSRID() int {
+	if c.defaultSRID == 0 && c.geography {
+		return geographyDefaultSRID
+	}
+	return c.defaultSRID
+}
+
+// withTypmod returns a copy of c that checks geometries against typmod when
+// they are encoded.
+func (c *geometryCodec) withTypmod(typmod GeometryTypmod) *geometryCodec {
+	codec := *c
+	codec.typmod = &typmod
+	return &codec
+}
+
+// validateGeom applies policy to geom.
+func validateGeom(policy ValidationPolicy, geom *geos.Geom) (*geos.Geom, error) {
+	switch policy {
+	case ValidationNone:
+	case ValidationReject:
+		if !geom.IsValid() {
+			return nil, fmt.Errorf("%s: %w", geom.IsValidReason(), ErrInvalidGeometry)
+		}
+	case ValidationMakeValid:
+		if !geom.IsValid() {
+			return geom.MakeValid(), nil
+		}
+	}
+	return geom, nil
+}
+
+// NewGeometryCodec returns a new codec for geometry values that uses
+// geosContext and opts. It can be registered on any
+// [github.com/jackc/pgx/v5/pgtype.Map].
+func NewGeometryCodec(geosContext *geos.Context, opts ...Option) pgtype.Codec {
+	return newGeometryCodec(newOptions(append([]Option{WithGEOSContext(geosContext)}, opts...)).forConnection())
+}
+
+// NewGeographyCodec returns a new codec for geography values that uses
+// geosContext and opts. It scans values as [Geography] and validates values on
+// encode. It can be registered on any [github.com/jackc/pgx/v5/pgtype.Map].
+// Only SRID 4326 and the SRIDs added with [WithGeodeticSRIDs] are accepted as
+// geodetic.
+func NewGeographyCodec(geosContext *geos.Context, opts ...Option) pgtype.Codec {
+	return newGeographyCodec(newOptions(append([]Option{WithGEOSContext(geosContext)}, opts...)).forConnection(), nil)
+}
+
+// newGeometryCodec returns a new geometryCodec with options.
+func newGeometryCodec(options *options) *geometryCodec {
+	return &geometryCodec{
+		geosContexts: options.contextPool,
+		defaultSRID:  options.defaultSRID,
+		coercions:    options.coercions,
+		validation:   options.validation,
+		scanHooks:    options.scanHooks,
+	}
+}
+
+// newGeographyCodec returns a new geometryCodec for geographies with options
+// that accepts the geodetic SRIDs in geodeticSRIDs, in options, and 4326.
+func newGeographyCodec(options *options, geodeticSRIDs []int) *geometryCodec {
+	codec := newGeometryCodec(options)
+	codec.geography = true
+	codec.geodeticSRIDs = make(map[int]struct{}, len(geodeticSRIDs)+len(options.geodeticSRIDs)+1)
+	codec.geodeticSRIDs[geographyDefaultSRID] = struct{}{}
+	for _, srid := range geodeticSRIDs {
+		codec.geodeticSRIDs[srid] = struct{}{}
+	}
+	for srid := range options.geodeticSRIDs {
+		codec.geodeticSRIDs[srid] = struct{}{}
+	}
+	return codec
 }
@@ -8,6 +8,8 @@ import (
 	"github.com/alecthomas/assert/v2"
 	"github.com/jackc/pgx/v5"
 	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
 )
 
 var geomTypes = []string{"geography", "geometry"}
@@ -112,7 +114,12 @@ func TestGeometryCodecScan(t *testing.T) {
 						values, err := rows.Values()
 						assert.NoError(t, err)
 						assert.Equal(t, 1, len(values))
-						assert.Equal(t, original.ToEWKBWithSRID(), values[0].(*geos.Geom).ToEWKBWithSRID()) //nolint:forcetypeassert
+						switch geomType {
+						case "geography":
+							assert.Equal(t, original.ToEWKBWithSRID(), values[0].(pgxgeos.Geography).ToEWKBWithSRID()) //nolint:forcetypeassert
+						default:
+							assert.Equal(t, original.ToEWKBWithSRID(), values[0].(*geos.Geom).ToEWKBWithSRID()) //nolint:forcetypeassert
+						}
 
 						assert.False(t, rows.Next())
 						assert.NoError(t, rows.Err())
@@ -0,0 +1,77 @@
+package pgxgeos
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// An EWKBHeader describes a geometry in EWKB format without parsing it with
+// GEOS.
+type EWKBHeader struct {
+	// ByteOrder is the byte order of the geometry, either
+	// [encoding/binary.BigEndian] or [encoding/binary.LittleEndian].
+	ByteOrder binary.ByteOrder
+	// Type is the geometry type.
+	Type GeometryType
+	// Z is set if the geometry has Z ordinates.
+	Z bool
+	// M is set if the geometry has M ordinates.
+	M bool
+	// HasSRID is set if the geometry includes an SRID.
+	HasSRID bool
+	// SRID is the SRID of the geometry, or zero if it has none.
+	SRID int
+	// NumParts is the number of points in a LineString or CircularString, the
+	// number of rings in a Polygon or Triangle, the number of geometries in a
+	// multi geometry, collection, compound curve, curve polygon, polyhedral
+	// surface, or TIN, and one for a Point, or zero if the Point is empty.
+	NumParts int
+	// IsEmpty is set if the geometry is empty.
+	IsEmpty bool
+}
+
+// InspectEWKB returns the header of the geometry in ewkb, which may be in EWKB
+// or ISO WKB format, in either byte order. Hex-encoded EWKB is also accepted.
+// It does not use GEOS and only reads as much of ewkb as is needed to
+// determine whether the geometry is empty. Errors wrap [ErrInvalidEWKB].
+func InspectEWKB(ewkb []byte) (EWKBHeader, error) {
+	if isHexEWKB(ewkb) {
+		scratch := getScratchBuffer()
+		defer putScratchBuffer(scratch)
+		var err error
+		if ewkb, err = hexDecodeScratch(scratch, ewkb); err != nil {
+			return EWKBHeader{}, fmt.Errorf("%w: %w", err, ErrInvalidEWKB)
+		}
+	}
+	header, err := parseEWKBHeader(ewkb)
+	if err != nil {
+		return EWKBHeader{}, err
+	}
+	if header.geometryType == GeometryTypeAny || header.geometryType > GeometryTypeTIN {
+		return EWKBHeader{}, fmt.Errorf("%d: unknown geometry type: %w", header.geometryType, ErrInvalidEWKB)
+	}
+	isEmpty, _, err := ewkbIsEmpty(ewkb)
+	if err != nil {
+		return EWKBHeader{}, err
+	}
+	numParts := 1
+	if header.geometryType != GeometryTypePoint {
+		n, _, err := readUint32(ewkb[header.size:], header.byteOrder)
+		if err != nil {
+			return EWKBHeader{}, err
+		}
+		numParts = int(n)
+	} else if isEmpty {
+		numParts = 0
+	}
+	return EWKBHeader{
+		ByteOrder: header.byteOrder,
+		Type:      header.geometryType,
+		Z:         header.z,
+		M:         header.m,
+		HasSRID:   header.hasSRID,
+		SRID:      header.srid,
+		NumParts:  numParts,
+		IsEmpty:   isEmpty,
+	}, nil
+}
@@ -0,0 +1,147 @@
+package pgxgeos_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestInspectEWKB(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		ewkb     []byte
+		expected pgxgeos.EWKBHeader
+	}{
+		{
+			name: "little_endian_point_zm_srid",
+			ewkb: appendPointEWKB(nil, 0xe0000001, 4326, 1, 2, 3, 4),
+			expected: pgxgeos.EWKBHeader{
+				ByteOrder: binary.LittleEndian,
+				Type:      pgxgeos.GeometryTypePoint,
+				Z:         true,
+				M:         true,
+				HasSRID:   true,
+				SRID:      4326,
+				NumParts:  1,
+			},
+		},
+		{
+			name: "big_endian_point_srid",
+			ewkb: []byte{
+				0x00, 0x20, 0x00, 0x00, 0x01, 0x00, 0x00, 0x10, 0xe6,
+				0x3f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x40, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			expected: pgxgeos.EWKBHeader{
+				ByteOrder: binary.BigEndian,
+				Type:      pgxgeos.GeometryTypePoint,
+				HasSRID:   true,
+				SRID:      4326,
+				NumParts:  1,
+			},
+		},
+		{
+			name: "big_endian_empty_multipolygon_z",
+			ewkb: []byte{0x00, 0x80, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x00},
+			expected: pgxgeos.EWKBHeader{
+				ByteOrder: binary.BigEndian,
+				Type:      pgxgeos.GeometryTypeMultiPolygon,
+				Z:         true,
+				IsEmpty:   true,
+			},
+		},
+		{
+			name: "linestring",
+			ewkb: newLineStringEWKB(3),
+			expected: pgxgeos.EWKBHeader{
+				ByteOrder: binary.LittleEndian,
+				Type:      pgxgeos.GeometryTypeLineString,
+				NumParts:  3,
+			},
+		},
+		{
+			name: "iso_point_zm",
+			ewkb: appendPointEWKB(nil, 3001, 0, 1, 2, 3, 4),
+			expected: pgxgeos.EWKBHeader{
+				ByteOrder: binary.LittleEndian,
+				Type:      pgxgeos.GeometryTypePoint,
+				Z:         true,
+				M:         true,
+				NumParts:  1,
+			},
+		},
+		{
+			name: "empty_point",
+			ewkb: appendPointEWKB(nil, 1, 0, math.NaN(), math.NaN()),
+			expected: pgxgeos.EWKBHeader{
+				ByteOrder: binary.LittleEndian,
+				Type:      pgxgeos.GeometryTypePoint,
+				IsEmpty:   true,
+			},
+		},
+		{
+			name: "collection_of_empty_point",
+			ewkb: appendPointEWKB([]byte{1, 7, 0, 0, 0, 1, 0, 0, 0}, 1, 0, math.NaN(), math.NaN()),
+			expected: pgxgeos.EWKBHeader{
+				ByteOrder: binary.LittleEndian,
+				Type:      pgxgeos.GeometryTypeGeometryCollection,
+				NumParts:  1,
+				IsEmpty:   true,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := pgxgeos.InspectEWKB(tc.ewkb)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+
+			actual, err = pgxgeos.InspectEWKB(hex.AppendEncode(nil, tc.ewkb))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestInspectEWKBGeometryTypes(t *testing.T) {
+	for geometryType := pgxgeos.GeometryTypePoint; geometryType <= pgxgeos.GeometryTypeTIN; geometryType++ {
+		t.Run(geometryType.String(), func(t *testing.T) {
+			var ewkb []byte
+			if geometryType == pgxgeos.GeometryTypePoint {
+				ewkb = appendPointEWKB(nil, uint32(geometryType), 0, math.NaN(), math.NaN())
+			} else {
+				ewkb = binary.BigEndian.AppendUint32([]byte{0}, uint32(geometryType))
+				ewkb = binary.BigEndian.AppendUint32(ewkb, 0)
+			}
+			actual, err := pgxgeos.InspectEWKB(ewkb)
+			assert.NoError(t, err)
+			assert.Equal(t, geometryType, actual.Type)
+			assert.True(t, actual.IsEmpty)
+			assert.Equal(t, 0, actual.NumParts)
+		})
+	}
+}
+
+func TestInspectEWKBError(t *testing.T) {
+	for i, ewkb := range [][]byte{
+		nil,
+		{1, 1, 0, 0},
+		{2, 1, 0, 0, 0},
+		{1, 0, 0, 0, 0, 0, 0, 0, 0},
+		{1, 16, 0, 0, 0, 0, 0, 0, 0},
+		{1, 1, 0, 0, 0x20},
+		{1, 2, 0, 0, 0},
+		appendPointEWKB(nil, 1, 0, 1),
+		[]byte("0101000000zz"),
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := pgxgeos.InspectEWKB(ewkb)
+			assert.IsError(t, err, pgxgeos.ErrInvalidEWKB)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package pgxgeos
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/twpayne/go-geos"
+)
+
+// A LazyGeom is a geometry that keeps the raw EWKB that it was scanned from
+// and is only parsed by GEOS when [LazyGeom.Geom] is first called, using the
+// GEOS context of the codec that scanned it. Its type, SRID, dimensions, and
+// emptiness are read from the EWKB without GEOS. A LazyGeom is encoded from
+// its EWKB without parsing it. The zero LazyGeom is NULL.
+//
+// A LazyGeom is not safe for concurrent use.
+type LazyGeom struct {
+	ewkb   []byte
+	header ewkbHeader
+	codec  *geometryCodec
+	geom   *geos.Geom
+	err    error
+}
+
+// EWKB returns g's EWKB, or nil if g is NULL. It must not be modified.
+func (g LazyGeom) EWKB() []byte {
+	return g.ewkb
+}
+
+// Geom returns g's geometry, parsing it with GEOS the first time that it is
+// called, with the scanning codec's validation policy and scan hooks applied.
+// It returns nil if g is NULL.
+func (g *LazyGeom) Geom() (*geos.Geom, error) {
+	if g.ewkb == nil || g.codec == nil {
+		return nil, nil
+	}
+	if g.geom == nil && g.err == nil {
+		g.geom, g.err = g.codec.decodeEWKB(g.ewkb)
+	}
+	return g.geom, g.err
+}
+
+// HasM returns whether g has M ordinates.
+func (g LazyGeom) HasM() bool {
+	return g.header.m
+}
+
+// HasZ returns whether g has Z ordinates.
+func (g LazyGeom) HasZ() bool {
+	return g.header.z
+}
+
+// IsEmpty returns whether g is empty. It reads no further into the EWKB than
+// the first coordinate.
+func (g LazyGeom) IsEmpty() bool {
+	if g.ewkb == nil {
+		return false
+	}
+	empty, _, err := ewkbIsEmpty(g.ewkb)
+	return err == nil && empty
+}
+
+// IsNull returns whether g is NULL.
+func (g LazyGeom) IsNull() bool {
+	return g.ewkb == nil
+}
+
+// MarshalEWKB implements [EWKBMarshaler.MarshalEWKB].
+func (g LazyGeom) MarshalEWKB() ([]byte, error) {
+	return g.ewkb, nil
+}
+
+// SRID returns g's SRID, or zero if it has none.
+func (g LazyGeom) SRID() int {
+	return g.header.srid
+}
+
+// Type returns g's geometry type.
+func (g LazyGeom) Type() GeometryType {
+	return g.header.geometryType
+}
+
+// set sets g to a copy of ewkb, which is nil if the value is NULL, to be
+// parsed later by codec.
+func (g *LazyGeom) set(codec *geometryCodec, ewkb []byte) error {
+	*g = LazyGeom{}
+	if ewkb == nil {
+		return nil
+	}
+	header, err := parseEWKBHeader(ewkb)
+	if err != nil {
+		return err
+	}
+	g.ewkb = bytes.Clone(ewkb)
+	g.header = header
+	g.codec = codec
+	return nil
+}
+
+// ewkbIsEmpty returns whether the geometry at the start of ewkb is empty and,
+// if it is, the remainder of ewkb. It returns as soon as it finds a non-empty
+// geometry.
+func ewkbIsEmpty(ewkb []byte) (bool, []byte, error) {
+	header, err := parseEWKBHeader(ewkb)
+	if err != nil {
+		return false, nil, err
+	}
+	ewkb = ewkb[header.size:]
+	switch header.geometryType {
+	case GeometryTypePoint:
+		size := 8 * header.dimensions()
+		if len(ewkb) < size {
+			return false, nil, fmt.Errorf("short coordinates: %w", ErrInvalidEWKB)
+		}
+		for i := 0; i < size; i += 8 {
+			if !math.IsNaN(readFloat64(ewkb[i:], header.byteOrder)) {
+				return false, nil, nil
+			}
+		}
+		return true, ewkb[size:], nil
+	case GeometryTypeMultiPoint, GeometryTypeMultiLineString, GeometryTypeMultiPolygon, GeometryTypeGeometryCollection,
+		GeometryTypeCompoundCurve, GeometryTypeCurvePolygon, GeometryTypeMultiCurve, GeometryTypeMultiSurface, GeometryTypePolyhedralSurface, GeometryTypeTIN:
+		n, ewkb, err := readUint32(ewkb, header.byteOrder)
+		if err != nil {
+			return false, nil, err
+		}
+		for range n {
+			var empty bool
+			if empty, ewkb, err = ewkbIsEmpty(ewkb); err != nil || !empty {
+				return false, nil, err
+			}
+		}
+		return true, ewkb, nil
+	default:
+		n, ewkb, err := readUint32(ewkb, header.byteOrder)
+		if err != nil || n != 0 {
+			return false, nil, err
+		}
+		return true, ewkb, nil
+	}
+}
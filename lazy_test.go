@@ -0,0 +1,152 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestLazyGeomHeader(t *testing.T) {
+	m := newGeometryTestMap()
+	for _, tc := range []struct {
+		name          string
+		ewkb          []byte
+		expectedType  pgxgeos.GeometryType
+		expectedSRID  int
+		expectedZ     bool
+		expectedM     bool
+		expectedEmpty bool
+	}{
+		{
+			name:         "linestring",
+			ewkb:         newLineStringEWKB(3),
+			expectedType: pgxgeos.GeometryTypeLineString,
+		},
+		{
+			name:          "empty_linestring",
+			ewkb:          newLineStringEWKB(0),
+			expectedType:  pgxgeos.GeometryTypeLineString,
+			expectedEmpty: true,
+		},
+		{
+			name:         "point_zm_srid",
+			ewkb:         appendPointEWKB(nil, 0xe0000001, 4326, 1, 2, 3, 4),
+			expectedType: pgxgeos.GeometryTypePoint,
+			expectedSRID: 4326,
+			expectedZ:    true,
+			expectedM:    true,
+		},
+		{
+			name:          "empty_point",
+			ewkb:          appendPointEWKB(nil, 1, 0, math.NaN(), math.NaN()),
+			expectedType:  pgxgeos.GeometryTypePoint,
+			expectedEmpty: true,
+		},
+		{
+			name:          "multipoint_of_empty_points",
+			ewkb:          appendPointEWKB(appendPointEWKB([]byte{1, 4, 0, 0, 0, 2, 0, 0, 0}, 1, 0, math.NaN(), math.NaN()), 1, 0, math.NaN(), math.NaN()),
+			expectedType:  pgxgeos.GeometryTypeMultiPoint,
+			expectedEmpty: true,
+		},
+		{
+			name:         "multipoint",
+			ewkb:         appendPointEWKB(appendPointEWKB([]byte{1, 4, 0, 0, 0, 2, 0, 0, 0}, 1, 0, math.NaN(), math.NaN()), 1, 0, 1, 2),
+			expectedType: pgxgeos.GeometryTypeMultiPoint,
+		},
+	} {
+		for format, formatName := range formatNames {
+			t.Run(tc.name+"/"+formatName, func(t *testing.T) {
+				var lazyGeom pgxgeos.LazyGeom
+				assert.NoError(t, m.Scan(geometryTestOID, format, encodeSrc(tc.ewkb, format), &lazyGeom))
+				assert.False(t, lazyGeom.IsNull())
+				assert.Equal(t, tc.ewkb, lazyGeom.EWKB())
+				assert.Equal(t, tc.expectedType, lazyGeom.Type())
+				assert.Equal(t, tc.expectedSRID, lazyGeom.SRID())
+				assert.Equal(t, tc.expectedZ, lazyGeom.HasZ())
+				assert.Equal(t, tc.expectedM, lazyGeom.HasM())
+				assert.Equal(t, tc.expectedEmpty, lazyGeom.IsEmpty())
+
+				buf, err := m.Encode(geometryTestOID, pgx.BinaryFormatCode, lazyGeom, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.ewkb, buf)
+			})
+		}
+	}
+
+	var lazyGeom pgxgeos.LazyGeom
+	assert.NoError(t, m.Scan(geometryTestOID, pgx.BinaryFormatCode, nil, &lazyGeom))
+	assert.True(t, lazyGeom.IsNull())
+	geom, err := lazyGeom.Geom()
+	assert.NoError(t, err)
+	assert.Zero(t, geom)
+
+	assert.Error(t, m.Scan(geometryTestOID, pgx.BinaryFormatCode, []byte{1, 1}, &lazyGeom))
+}
+
+func TestLazyGeom(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		var lazyGeom pgxgeos.LazyGeom
+		assert.NoError(tb, conn.QueryRow(ctx, "select 'SRID=4326;POINT(1 2)'::geometry").Scan(&lazyGeom))
+		assert.Equal(tb, pgxgeos.GeometryTypePoint, lazyGeom.Type())
+		assert.Equal(tb, 4326, lazyGeom.SRID())
+
+		geom, err := lazyGeom.Geom()
+		assert.NoError(tb, err)
+		assert.True(tb, geom.Equals(mustNewGeomFromWKT(tb, "POINT (1 2)")))
+		assert.Equal(tb, 4326, geom.SRID())
+		cachedGeom, err := lazyGeom.Geom()
+		assert.NoError(tb, err)
+		assert.True(tb, geom == cachedGeom)
+
+		var actual string
+		assert.NoError(tb, conn.QueryRow(ctx, "select ST_AsEWKT($1::geometry)", &lazyGeom).Scan(&actual))
+		assert.Equal(tb, "SRID=4326;POINT(1 2)", actual)
+
+		assert.NoError(tb, conn.QueryRow(ctx, "select NULL::geometry").Scan(&lazyGeom))
+		assert.True(tb, lazyGeom.IsNull())
+	})
+}
+
+func TestLazyGeomScanHook(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		calls := 0
+		_, err := pgxgeos.RegisterWithOptions(ctx, conn, pgxgeos.WithScanHook(func(value any) error {
+			if _, ok := value.(*geos.Geom); ok {
+				calls++
+			}
+			return nil
+		}))
+		assert.NoError(tb, err)
+		var lazyGeom pgxgeos.LazyGeom
+		assert.NoError(tb, conn.QueryRow(ctx, "select 'POINT(1 2)'::geometry").Scan(&lazyGeom))
+		assert.Equal(tb, 0, calls)
+		_, err = lazyGeom.Geom()
+		assert.NoError(tb, err)
+		_, err = lazyGeom.Geom()
+		assert.NoError(tb, err)
+		assert.Equal(tb, 1, calls)
+	})
+}
+
+// appendPointEWKB appends a little-endian EWKB point with geometry type word
+// typeWord to ewkb. srid is included if typeWord has the SRID flag set.
+func appendPointEWKB(ewkb []byte, typeWord uint32, srid int, coords ...float64) []byte {
+	ewkb = append(ewkb, 1)
+	ewkb = binary.LittleEndian.AppendUint32(ewkb, typeWord)
+	if typeWord&0x20000000 != 0 {
+		ewkb = binary.LittleEndian.AppendUint32(ewkb, uint32(srid)) //nolint:gosec
+	}
+	for _, coord := range coords {
+		ewkb = binary.LittleEndian.AppendUint64(ewkb, math.Float64bits(coord))
+	}
+	return ewkb
+}
@@ -0,0 +1,25 @@
+package pgxgeos
+
+// An EWKBMarshaler is a geometry that can marshal itself to WKB or EWKB.
+// Values implementing EWKBMarshaler or [encoding.BinaryMarshaler], which must
+// return WKB or EWKB, can be encoded as geometries, which allows types from
+// other geometry libraries to be used without conversion. If MarshalEWKB
+// returns nil then NULL is encoded.
+//
+// The default SRID and typmod are applied to marshaled geometries, but they
+// are not otherwise validated.
+type EWKBMarshaler interface {
+	MarshalEWKB() ([]byte, error)
+}
+
+// An EWKBUnmarshaler is a geometry that can unmarshal itself from EWKB.
+// Geometries can be scanned into targets implementing EWKBUnmarshaler, which
+// allows types from other geometry libraries to be used without conversion.
+// UnmarshalEWKB is called with nil if the value is NULL. It must copy ewkb if
+// it wishes to retain it after returning.
+//
+// Scan hooks and validation policies are not applied to unmarshaled
+// geometries.
+type EWKBUnmarshaler interface {
+	UnmarshalEWKB(ewkb []byte) error
+}
@@ -0,0 +1,60 @@
+package pgxgeos_test
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// An ewkbGeom is a geometry type from another library that marshals itself
+// to and from EWKB.
+type ewkbGeom struct {
+	ewkb []byte
+}
+
+func (g ewkbGeom) MarshalEWKB() ([]byte, error) {
+	return g.ewkb, nil
+}
+
+func (g *ewkbGeom) UnmarshalEWKB(ewkb []byte) error {
+	g.ewkb = bytes.Clone(ewkb)
+	return nil
+}
+
+// A binaryGeom is a geometry type from another library that implements
+// encoding.BinaryMarshaler with WKB.
+type binaryGeom struct {
+	wkb []byte
+}
+
+func (g binaryGeom) MarshalBinary() ([]byte, error) {
+	return g.wkb, nil
+}
+
+func TestEWKBMarshaler(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, format := range []int16{
+			pgx.BinaryFormatCode,
+			pgx.TextFormatCode,
+		} {
+			tb.(*testing.T).Run(strconv.Itoa(int(format)), func(t *testing.T) { //nolint:forcetypeassert
+				geom := mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(4326)
+
+				var actual ewkbGeom
+				assert.NoError(t, conn.QueryRow(ctx, "select $1::geometry", pgx.QueryResultFormats{format}, ewkbGeom{ewkb: geom.ToEWKBWithSRID()}).Scan(&actual))
+				assert.Equal(t, geom.ToEWKBWithSRID(), actual.ewkb)
+
+				assert.NoError(t, conn.QueryRow(ctx, "select $1::geometry", pgx.QueryResultFormats{format}, binaryGeom{wkb: geom.ToEWKBWithSRID()}).Scan(&actual))
+				assert.Equal(t, geom.ToEWKBWithSRID(), actual.ewkb)
+
+				assert.NoError(t, conn.QueryRow(ctx, "select $1::geometry", pgx.QueryResultFormats{format}, ewkbGeom{}).Scan(&actual))
+				assert.Zero(t, actual.ewkb)
+			})
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package pgxgeos
+
+import "github.com/twpayne/go-geos"
+
+// A Coercion is a way in which geometries are changed to match the typmod of
+// the type that they are encoded as, for example a domain created as
+// geometry(MultiPolygon, 4326). Coercions can be combined with | to form sets
+// of coercions.
+type Coercion uint
+
+// Coercions.
+const (
+	// CoerceSRID sets the SRID of geometries with no SRID to the typmod's
+	// SRID.
+	//
+	// Deprecated: Geometries with no SRID are always given the typmod's SRID,
+	// as in PostGIS.
+	CoerceSRID Coercion = 1 << iota
+	// CoerceForce2D removes Z and M ordinates that are not in the typmod.
+	CoerceForce2D
+	// CoerceMulti promotes Points, LineStrings, and Polygons to MultiPoints,
+	// MultiLineStrings, and MultiPolygons if the typmod has a multi type.
+	CoerceMulti
+)
+
+// A ValidationPolicy determines how invalid values are handled when they are
+// encoded or scanned.
+type ValidationPolicy int
+
+// Validation policies.
+const (
+	// ValidationNone does not validate values.
+	ValidationNone ValidationPolicy = iota
+	// ValidationReject returns an error wrapping [ErrInvalidGeometry] for
+	// invalid geometries and boxes whose minimums exceed their maximums.
+	ValidationReject
+	// ValidationMakeValid replaces invalid geometries with valid ones and
+	// swaps the minimums and maximums of boxes where needed.
+	ValidationMakeValid
+)
+
+// A ScanHook is called with each value scanned, which is a
+// [*github.com/twpayne/go-geos.Geom], [*github.com/twpayne/go-geos.Box2D], or
+// [*github.com/twpayne/go-geos.Box3D]. It may modify the value in place. If it
+// returns an error then the scan fails with that error.
+type ScanHook func(value any) error
+
+// An Option sets an option on the registration of codecs.
+type Option func(*options)
+
+// options contains the options for the registration of codecs.
+type options struct {
+	aliases              map[string][]string
+	boxMaxZ              float64
+	boxMinZ              float64
+	coercions            Coercion
+	contextPerConnection bool
+	contextPool          ContextPool
+	defaultSRID          int
+	extension            string
+	geodeticSRIDs        map[int]struct{}
+	required             Type
+	scanHooks            []ScanHook
+	schema               string
+	types                Type
+	validation           ValidationPolicy
+}
+
+// WithCoercions sets the coercions applied to geometries that do not match the
+// typmod of the type that they are encoded as. Geometries that still do not
+// match are rejected with a [*TypmodError]. PostgreSQL does not report the
+// typmods of parameters, so typmods are only known for domains, for example
+// those created with create domain point_4326 as geometry(Point, 4326).
+func WithCoercions(coercions Coercion) Option {
+	return func(o *options) {
+		o.coercions = coercions
+	}
+}
+
+// WithContextPerConnection creates a new GEOS context for each connection on
+// which codecs are registered, so that connections do not contend for a shared
+// context. It takes precedence over [WithGEOSContext] and [WithContextPool].
+func WithContextPerConnection() Option {
+	return func(o *options) {
+		o.contextPerConnection = true
+	}
+}
+
+// WithContextPool sets the pool from which codecs take the GEOS contexts used
+// to create geometries, for example one returned by [NewContextPool]. The pool
+// may be shared by many connections.
+func WithContextPool(pool ContextPool) Option {
+	return func(o *options) {
+		o.contextPool = pool
+	}
+}
+
+// WithDefaultBoxZ sets the Z bounds of box2d values when they are scanned into
+// [github.com/twpayne/go-geos.Box3D]s or encoded from
+// [github.com/twpayne/go-geos.Box2D]s as box3d values. The default is zero.
+func WithDefaultBoxZ(minZ, maxZ float64) Option {
+	return func(o *options) {
+		o.boxMinZ = minZ
+		o.boxMaxZ = maxZ
+	}
+}
+
+// WithDefaultSRID sets the SRID of geometries without an SRID when they are
+// encoded. The geometries themselves are not modified.
+func WithDefaultSRID(srid int) Option {
+	return func(o *options) {
+		o.defaultSRID = srid
+	}
+}
+
+// WithExtension looks up the PostGIS types as members of the extension named
+// extension, typically "postgis", regardless of the search_path. It takes
+// precedence over [WithSchema].
+func WithExtension(extension string) Option {
+	return func(o *options) {
+		o.extension = extension
+	}
+}
+
+// WithGeodeticSRIDs adds srids to the geodetic SRIDs accepted for geography
+// values. By default, 4326 and the SRIDs of the longitude/latitude coordinate
+// reference systems in spatial_ref_sys are accepted. It is needed when
+// spatial_ref_sys cannot be read, for example with [NewGeographyCodec].
+func WithGeodeticSRIDs(srids ...int) Option {
+	return func(o *options) {
+		for _, srid := range srids {
+			o.geodeticSRIDs[srid] = struct{}{}
+		}
+	}
+}
+
+// WithGEOSContext sets the GEOS context used to create geometries. If
+// geosContext is nil then [github.com/twpayne/go-geos.DefaultContext] is used.
+func WithGEOSContext(geosContext *geos.Context) Option {
+	return func(o *options) {
+		if geosContext == nil {
+			o.contextPool = nil
+			return
+		}
+		o.contextPool = singleContextPool{
+			geosContext: geosContext,
+		}
+	}
+}
+
+// WithRequiredTypes sets the types that must exist in the database. By default
+// no types are required and codecs are registered for whichever types exist.
+func WithRequiredTypes(types Type) Option {
+	return func(o *options) {
+		o.required |= types
+	}
+}
+
+// WithScanHook adds hook to the hooks called when values are scanned. Hooks are
+// called in the order in which they were added.
+func WithScanHook(hook ScanHook) Option {
+	return func(o *options) {
+		o.scanHooks = append(o.scanHooks, hook)
+	}
+}
+
+// WithSchema looks up the PostGIS types in schema, regardless of the
+// search_path.
+func WithSchema(schema string) Option {
+	return func(o *options) {
+		o.schema = schema
+	}
+}
+
+// WithTypeAlias registers the codec for the type named name, for example
+// "geometry", under the additional name alias.
+func WithTypeAlias(alias, name string) Option {
+	return func(o *options) {
+		if o.aliases == nil {
+			o.aliases = make(map[string][]string)
+		}
+		o.aliases[name] = append(o.aliases[name], alias)
+	}
+}
+
+// WithTypes sets the types for which codecs are registered. By default codecs
+// are registered for all types.
+func WithTypes(types Type) Option {
+	return func(o *options) {
+		o.types = types
+	}
+}
+
+// WithValidationPolicy sets the validation policy. The default is
+// [ValidationNone].
+func WithValidationPolicy(validation ValidationPolicy) Option {
+	return func(o *options) {
+		o.validation = validation
+	}
+}
+
+// runScanHooks calls each of hooks with value.
+func runScanHooks(hooks []ScanHook, value any) error {
+	for _, hook := range hooks {
+		if err := hook(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newOptions returns the options set by opts.
+func newOptions(opts []Option) *options {
+	o := &options{
+		geodeticSRIDs: make(map[int]struct{}),
+		types:         AllTypes,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.contextPool == nil {
+		o.contextPool = singleContextPool{
+			geosContext: geos.DefaultContext,
+		}
+	}
+	return o
+}
+
+// forConnection returns the options for registering codecs on a single
+// connection, which has its own GEOS context if o.contextPerConnection is set.
+func (o *options) forConnection() *options {
+	if !o.contextPerConnection {
+		return o
+	}
+	connOptions := *o
+	connOptions.contextPool = NewContextPool(1)
+	return &connOptions
+}
@@ -0,0 +1,190 @@
+package pgxgeos
+
+import (
+	"encoding/hex"
+	"runtime"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/twpayne/go-geos"
+)
+
+// parallelRowsPerWorker is the number of rows queued per worker.
+const parallelRowsPerWorker = 64
+
+// A ParallelDecoder decodes the geometry and geography columns of query
+// results on several goroutines, each with its own GEOS context, while
+// preserving the order of rows. It is useful when a single connection streams
+// large result sets, which would otherwise be decoded on a single core.
+//
+// Scan hooks are called concurrently, so they must be safe for concurrent use.
+type ParallelDecoder struct {
+	contexts ContextPool
+	workers  int
+}
+
+// A parallelRow is a row being decoded by a ParallelDecoder.
+type parallelRow struct {
+	rawValues [][]byte
+	values    []any
+	err       error
+	done      chan struct{}
+}
+
+// NewParallelDecoder returns a new ParallelDecoder with workers goroutines,
+// each of which takes one GEOS context from contexts. If workers is less than
+// one then [runtime.GOMAXPROCS] goroutines are used. If contexts is nil then a
+// new pool with one context per goroutine is used.
+func NewParallelDecoder(workers int, contexts ContextPool) *ParallelDecoder {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if contexts == nil {
+		contexts = NewContextPool(workers)
+	}
+	return &ParallelDecoder{
+		contexts: contexts,
+		workers:  workers,
+	}
+}
+
+// ForEachRow reads all of rows and calls fn with the values of each row, in
+// order. The values are as returned by [github.com/jackc/pgx/v5.Rows.Values],
+// except that geometry and geography columns are decoded in parallel. If fn
+// returns an error then ForEachRow stops and returns that error. rows is
+// closed when ForEachRow returns.
+func (d *ParallelDecoder) ForEachRow(rows pgx.Rows, fn func(values []any) error) error {
+	defer rows.Close()
+
+	m := rows.Conn().TypeMap()
+	fieldDescriptions := rows.FieldDescriptions()
+	codecs := make([]*geometryCodec, len(fieldDescriptions))
+	for i, fieldDescription := range fieldDescriptions {
+		if t, ok := m.TypeForOID(fieldDescription.DataTypeOID); ok {
+			codecs[i], _ = t.Codec.(*geometryCodec)
+		}
+	}
+
+	rowsCh := make(chan *parallelRow, d.workers*parallelRowsPerWorker)
+	var wg sync.WaitGroup
+	for range d.workers {
+		geosContext := d.contexts.Context()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range rowsCh {
+				row.decodeGeoms(geosContext, codecs, fieldDescriptions)
+				close(row.done)
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(rowsCh)
+
+	var pending []*parallelRow
+	flush := func(n int) error {
+		for len(pending) > n {
+			row := pending[0]
+			pending = pending[1:]
+			<-row.done
+			if row.err != nil {
+				return row.err
+			}
+			if err := row.decodeValues(m, codecs, fieldDescriptions); err != nil {
+				return err
+			}
+			if err := fn(row.values); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for rows.Next() {
+		row := &parallelRow{
+			rawValues: cloneRawValues(rows.RawValues()),
+			values:    make([]any, len(fieldDescriptions)),
+			done:      make(chan struct{}),
+		}
+		rowsCh <- row
+		pending = append(pending, row)
+		if err := flush(cap(rowsCh)); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush(0)
+}
+
+// decodeGeoms decodes the values of r in the columns that have a codec in
+// codecs with geosContext.
+func (r *parallelRow) decodeGeoms(geosContext *geos.Context, codecs []*geometryCodec, fieldDescriptions []pgconn.FieldDescription) {
+	for i, codec := range codecs {
+		if codec == nil || r.rawValues[i] == nil {
+			continue
+		}
+		ewkb := r.rawValues[i]
+		if fieldDescriptions[i].Format == pgtype.TextFormatCode {
+			var err error
+			if ewkb, err = hex.AppendDecode(nil, ewkb); err != nil {
+				r.err = err
+				return
+			}
+		}
+		geom, err := codec.decodeEWKBWithContext(geosContext, ewkb)
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.values[i] = codec.value(geom)
+	}
+}
+
+// decodeValues decodes the values of r in the columns that do not have a codec
+// in codecs with m.
+func (r *parallelRow) decodeValues(m *pgtype.Map, codecs []*geometryCodec, fieldDescriptions []pgconn.FieldDescription) error {
+	for i, fieldDescription := range fieldDescriptions {
+		if codecs[i] != nil || r.rawValues[i] == nil {
+			continue
+		}
+		t, ok := m.TypeForOID(fieldDescription.DataTypeOID)
+		if !ok {
+			if fieldDescription.Format == pgtype.TextFormatCode {
+				r.values[i] = string(r.rawValues[i])
+			} else {
+				r.values[i] = r.rawValues[i]
+			}
+			continue
+		}
+		value, err := t.Codec.DecodeValue(m, fieldDescription.DataTypeOID, fieldDescription.Format, r.rawValues[i])
+		if err != nil {
+			return err
+		}
+		r.values[i] = value
+	}
+	return nil
+}
+
+// cloneRawValues returns a copy of rawValues, which are only valid until the
+// next row is read, in a single allocation.
+func cloneRawValues(rawValues [][]byte) [][]byte {
+	n := 0
+	for _, rawValue := range rawValues {
+		n += len(rawValue)
+	}
+	data := make([]byte, 0, n)
+	clone := make([][]byte, len(rawValues))
+	for i, rawValue := range rawValues {
+		if rawValue == nil {
+			continue
+		}
+		start := len(data)
+		data = append(data, rawValue...)
+		clone[i] = data[start:len(data):len(data)]
+	}
+	return clone
+}
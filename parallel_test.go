@@ -0,0 +1,60 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestParallelDecoder(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, format := range []int16{
+			pgx.BinaryFormatCode,
+			pgx.TextFormatCode,
+		} {
+			rows, err := conn.Query(ctx, "select i, case when i % 10 = 0 then null else ST_MakePoint(i, -i) end, ST_MakePoint(i, i)::geography from generate_series(1, 10000) as i", pgx.QueryResultFormats{format})
+			assert.NoError(tb, err)
+			expectedI := int32(0)
+			assert.NoError(tb, pgxgeos.NewParallelDecoder(4, nil).ForEachRow(rows, func(values []any) error {
+				expectedI++
+				assert.Equal(tb, expectedI, values[0].(int32)) //nolint:forcetypeassert
+				if expectedI%10 == 0 {
+					assert.Zero(tb, values[1])
+				} else {
+					geom := values[1].(*geos.Geom) //nolint:forcetypeassert
+					assert.Equal(tb, float64(expectedI), geom.X())
+					assert.Equal(tb, -float64(expectedI), geom.Y())
+				}
+				geography := values[2].(pgxgeos.Geography) //nolint:forcetypeassert
+				assert.Equal(tb, float64(expectedI), geography.X())
+				return nil
+			}))
+			assert.Equal(tb, 10000, expectedI)
+		}
+	})
+}
+
+func TestParallelDecoderError(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		errStop := errors.New("stop")
+		rows, err := conn.Query(ctx, "select ST_MakePoint(i, i) from generate_series(1, 10000) as i")
+		assert.NoError(tb, err)
+		n := 0
+		assert.IsError(tb, pgxgeos.NewParallelDecoder(0, pgxgeos.NewContextPool(2)).ForEachRow(rows, func(values []any) error {
+			n++
+			if n == 100 {
+				return errStop
+			}
+			return nil
+		}), errStop)
+		assert.Equal(tb, 100, n)
+	})
+}
@@ -3,16 +3,404 @@ package pgxgeos
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/twpayne/go-geos"
 )
 
+// A Type is a PostGIS type. Types can be combined with | to form sets of types.
+type Type uint
+
+// Types.
+const (
+	TypeBox2D Type = 1 << iota
+	TypeBox3D
+	TypeGeography
+	TypeGeometry
+	TypeGeometryDump
+	TypeValidDetail
+
+	AllTypes = TypeBox2D | TypeBox3D | TypeGeography | TypeGeometry | TypeGeometryDump | TypeValidDetail
+)
+
+// Errors.
+var (
+	ErrDimensionMismatch    = errors.New("dimension mismatch")
+	ErrGeometryTypeMismatch = errors.New("geometry type mismatch")
+	ErrInvalidEWKB          = errors.New("invalid EWKB")
+	ErrInvalidGeography     = errors.New("invalid geography")
+	ErrInvalidGeometry      = errors.New("invalid geometry")
+	ErrInvalidTypmod        = errors.New("invalid typmod")
+	ErrNotGeometry          = errors.New("not a geometry")
+	ErrSRIDMismatch         = errors.New("SRID mismatch")
+	ErrTypeNotFound         = errors.New("type not found")
+)
+
+// Queries returning the name, OID, array OID, and schema of each of the types
+// named in $1.
+const (
+	searchPathOIDsSQL = `select t.typname, t.oid, t.typarray, n.nspname
+		from unnest($1::text[]) as name
+		join pg_type t on t.oid = to_regtype(name)
+		join pg_namespace n on n.oid = t.typnamespace`
+	schemaOIDsSQL = `select t.typname, t.oid, t.typarray, n.nspname
+		from pg_type t
+		join pg_namespace n on n.oid = t.typnamespace
+		where t.typname = any($1) and n.nspname = $2`
+	extensionOIDsSQL = `select t.typname, t.oid, t.typarray, n.nspname
+		from pg_extension e
+		join pg_depend d on d.refclassid = 'pg_extension'::regclass and d.refobjid = e.oid and d.classid = 'pg_type'::regclass and d.deptype = 'e'
+		join pg_type t on t.oid = d.objid
+		join pg_namespace n on n.oid = t.typnamespace
+		where t.typname = any($1) and e.extname = $2`
+)
+
+// domainsSQL extends a query returning the name, OID, array OID, and schema of
+// PostGIS types with the domains over them, recursively. It returns the name,
+// OID, array OID, and schema of each type and domain, the name of the PostGIS
+// type that it is based on, whether it is a domain, and its typmod, which is
+// inherited from the domain or type that it is based on if it has none. For
+// the geography type, it also returns the SRIDs of the longitude/latitude
+// coordinate reference systems in the spatial_ref_sys table in the same
+// schema, if it exists, which is read with query_to_xml because its schema is
+// only known at run time.
+const domainsSQL = `with recursive base(name, oid, arrayoid, schema) as (%s),
+	types(name, oid, arrayoid, schema, base, domain, typmod) as (
+		select name, oid, arrayoid, schema, name, false, -1 from base
+		union all
+		select t.typname, t.oid, t.typarray, n.nspname, types.base, true,
+			case when t.typtypmod >= 0 then t.typtypmod else types.typmod end
+		from types
+		join pg_type t on t.typbasetype = types.oid and t.typtype = 'd'
+		join pg_namespace n on n.oid = t.typnamespace
+	)
+	select name, oid, arrayoid, schema, base, domain, typmod,
+		case when name = 'geography' and not domain and to_regclass(format('%%I.spatial_ref_sys', schema)) is not null then
+			array(select srid::text::int from unnest(xpath('//row/srid/text()', query_to_xml(
+				format('select srid from %%I.spatial_ref_sys where proj4text like %%L', schema, '%%+proj=longlat%%'),
+				false, false, ''))) as srid)
+		end
+	from types`
+
+// typeNames maps types to their PostgreSQL names.
+var typeNames = []struct {
+	t    Type
+	name string
+}{
+	{TypeBox2D, "box2d"},
+	{TypeBox3D, "box3d"},
+	{TypeGeography, "geography"},
+	{TypeGeometry, "geometry"},
+	{TypeGeometryDump, "geometry_dump"},
+	{TypeValidDetail, "valid_detail"},
+}
+
+// A Report describes which types were registered.
+type Report struct {
+	Registered Type
+	Missing    Type
+	Domains    []string
+}
+
+// TypeOIDs contains the OIDs of a type and its array type. The OIDs of a type
+// that does not exist are zero.
+type TypeOIDs struct {
+	OID      uint32
+	ArrayOID uint32
+}
+
+// A DomainOIDs contains the OIDs of a domain over a PostGIS type. Typmod is
+// the domain's typmod, for example the typmod of geometry(Point, 4326), or -1
+// if it has none.
+type DomainOIDs struct {
+	TypeOIDs
+	Name   string
+	Schema string
+	Base   Type
+	Typmod int32
+}
+
+// OIDs contains the OIDs of the PostGIS types and the domains over them.
+// Schema is the schema containing the PostGIS types, if known. GeodeticSRIDs
+// are the SRIDs of the longitude/latitude coordinate reference systems in
+// spatial_ref_sys, which are accepted for geography values.
+type OIDs struct {
+	Schema        string
+	Box2D         TypeOIDs
+	Box3D         TypeOIDs
+	Geography     TypeOIDs
+	Geometry      TypeOIDs
+	GeometryDump  TypeOIDs
+	ValidDetail   TypeOIDs
+	Domains       []DomainOIDs
+	GeodeticSRIDs []int
+}
+
 // Register registers codecs for [github.com/twpayne/go-geos] types on conn.
+// Codecs are registered for whichever PostGIS types exist.
 func Register(ctx context.Context, conn *pgx.Conn, geosContext *geos.Context) error {
-	return errors.Join(
-		registerBox2D(ctx, conn),
-		registerBox3D(ctx, conn),
-		registerGeom(ctx, conn, geosContext),
-	)
+	_, err := RegisterWithOptions(ctx, conn, WithGEOSContext(geosContext))
+	return err
+}
+
+// RegisterWithOptions registers codecs for [github.com/twpayne/go-geos] types
+// on conn with opts and returns a report of the types registered. It returns
+// an error wrapping [ErrTypeNotFound] if any required type does not exist.
+func RegisterWithOptions(ctx context.Context, conn *pgx.Conn, opts ...Option) (*Report, error) {
+	options := newOptions(opts)
+	oids, err := loadOIDs(ctx, conn, options)
+	if err != nil {
+		return nil, err
+	}
+	return registerOIDs(conn.TypeMap(), oids, options)
+}
+
+// LoadOIDs returns the OIDs of the PostGIS types on conn with opts. Only the
+// [WithExtension] and [WithSchema] options affect the lookup.
+func LoadOIDs(ctx context.Context, conn *pgx.Conn, opts ...Option) (OIDs, error) {
+	return loadOIDs(ctx, conn, newOptions(opts))
+}
+
+// RegisterTypeMap registers codecs for [github.com/twpayne/go-geos] types
+// with the given oids on m with opts and returns a report of the types
+// registered. It does not require a connection, so it can be used to build
+// type maps offline, for example from OIDs returned by [LoadOIDs]. It returns
+// an error wrapping [ErrTypeNotFound] if any required type has a zero OID or
+// is a composite type whose field types are not registered.
+func RegisterTypeMap(m *pgtype.Map, oids OIDs, opts ...Option) (*Report, error) {
+	return registerOIDs(m, oids, newOptions(opts))
+}
+
+// String returns the PostgreSQL names of t, separated by commas.
+func (t Type) String() string {
+	var names []string
+	for _, typeName := range typeNames {
+		if t&typeName.t != 0 {
+			names = append(names, typeName.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// oid returns the OIDs of t.
+func (o *OIDs) oid(t Type) *TypeOIDs {
+	switch t {
+	case TypeBox2D:
+		return &o.Box2D
+	case TypeBox3D:
+		return &o.Box3D
+	case TypeGeography:
+		return &o.Geography
+	case TypeGeometry:
+		return &o.Geometry
+	case TypeGeometryDump:
+		return &o.GeometryDump
+	case TypeValidDetail:
+		return &o.ValidDetail
+	default:
+		return nil
+	}
+}
+
+// types returns the types that exist.
+func (o *OIDs) types() Type {
+	var types Type
+	for _, typeName := range typeNames {
+		if o.oid(typeName.t).OID != 0 {
+			types |= typeName.t
+		}
+	}
+	return types
+}
+
+// loadOIDs returns the OIDs of the PostGIS types in a single query.
+func loadOIDs(ctx context.Context, conn *pgx.Conn, options *options) (OIDs, error) {
+	names := make([]string, 0, len(typeNames))
+	for _, typeName := range typeNames {
+		names = append(names, typeName.name)
+	}
+
+	var rows pgx.Rows
+	var err error
+	switch {
+	case options.extension != "":
+		rows, err = conn.Query(ctx, fmt.Sprintf(domainsSQL, extensionOIDsSQL), names, options.extension)
+	case options.schema != "":
+		rows, err = conn.Query(ctx, fmt.Sprintf(domainsSQL, schemaOIDsSQL), names, options.schema)
+	default:
+		rows, err = conn.Query(ctx, fmt.Sprintf(domainsSQL, searchPathOIDsSQL), names)
+	}
+	if err != nil {
+		return OIDs{}, err
+	}
+	defer rows.Close()
+
+	var oids OIDs
+	for rows.Next() {
+		var name, schema, baseName string
+		var typeOIDs TypeOIDs
+		var domain bool
+		var typmod int32
+		var geodeticSRIDs []int
+		if err := rows.Scan(&name, &typeOIDs.OID, &typeOIDs.ArrayOID, &schema, &baseName, &domain, &typmod, &geodeticSRIDs); err != nil {
+			return oids, err
+		}
+		if geodeticSRIDs != nil {
+			oids.GeodeticSRIDs = geodeticSRIDs
+		}
+		base, ok := typeForName(baseName)
+		if !ok {
+			continue
+		}
+		if domain {
+			oids.Domains = append(oids.Domains, DomainOIDs{
+				TypeOIDs: typeOIDs,
+				Name:     name,
+				Schema:   schema,
+				Base:     base,
+				Typmod:   typmod,
+			})
+		} else {
+			*oids.oid(base) = typeOIDs
+			oids.Schema = schema
+		}
+	}
+	return oids, rows.Err()
+}
+
+// registerOIDs registers codecs for [github.com/twpayne/go-geos] types on m.
+func registerOIDs(m *pgtype.Map, oids OIDs, options *options) (*Report, error) {
+	options = options.forConnection()
+	types := oids.types() & (options.types | options.required)
+	missing := (options.types | options.required) &^ oids.types()
+	if missing&options.required != 0 {
+		return &Report{
+			Missing: missing,
+		}, fmt.Errorf("%s: %w", missing&options.required, ErrTypeNotFound)
+	}
+
+	codecs := make(map[Type]pgtype.Codec)
+	for _, typeName := range typeNames {
+		if types&typeName.t == 0 {
+			continue
+		}
+		codec := newCodec(m, typeName.t, &oids, options)
+		if codec == nil {
+			if options.required&typeName.t != 0 {
+				return &Report{
+					Missing: typeName.t,
+				}, fmt.Errorf("%s: field types not registered: %w", typeName.t, ErrTypeNotFound)
+			}
+			types &^= typeName.t
+			continue
+		}
+		registerType(m, codec, *oids.oid(typeName.t), oids.names(typeName.name, options))
+		codecs[typeName.t] = codec
+	}
+	if types&TypeGeometry != 0 {
+		registerGeometryFormats(m, newGeometryCodec(options))
+	}
+	var domains []string
+	for _, domain := range oids.Domains {
+		codec, ok := codecs[domain.Base]
+		if !ok {
+			continue
+		}
+		if geometryCodec, ok := codec.(*geometryCodec); ok && domain.Typmod != -1 {
+			typmod, err := ParseTypmod(domain.Typmod)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", domain.Schema, domain.Name, err)
+			}
+			codec = geometryCodec.withTypmod(typmod)
+		}
+		qualifiedName := domain.Schema + "." + domain.Name
+		registerType(m, codec, domain.TypeOIDs, []string{qualifiedName, domain.Name})
+		domains = append(domains, qualifiedName)
+	}
+	return &Report{
+		Registered: types,
+		Missing:    missing,
+		Domains:    domains,
+	}, nil
+}
+
+// names returns the names under which the type name is registered: any
+// aliases, the schema-qualified name, if the schema is known, and the
+// unqualified name.
+func (o *OIDs) names(name string, options *options) []string {
+	names := append([]string(nil), options.aliases[name]...)
+	if o.Schema != "" {
+		names = append(names, o.Schema+"."+name)
+	}
+	return append(names, name)
+}
+
+// newCodec returns a new codec for t with oids and options. Composite types
+// use the types already registered on m for their fields. It returns nil if
+// the codec cannot be created.
+func newCodec(m *pgtype.Map, t Type, oids *OIDs, options *options) pgtype.Codec {
+	switch t {
+	case TypeBox2D:
+		return newBox2DCodec(options)
+	case TypeBox3D:
+		return newBox3DCodec(options)
+	case TypeGeography:
+		return newGeographyCodec(options, oids.GeodeticSRIDs)
+	case TypeGeometryDump:
+		return newGeometryDumpCodec(m)
+	case TypeValidDetail:
+		return newValidDetailCodec(m)
+	default:
+		return newGeometryCodec(options)
+	}
+}
+
+// typeForName returns the type with the given PostgreSQL name.
+func typeForName(name string) (Type, bool) {
+	for _, typeName := range typeNames {
+		if typeName.name == name {
+			return typeName.t, true
+		}
+	}
+	return 0, false
+}
+
+// registerType registers codec on m under each of names, and an array codec
+// for its array type under each of names with an underscore prefix. The last
+// name is the name returned by [github.com/jackc/pgx/v5/pgtype.Map.TypeForOID].
+func registerType(m *pgtype.Map, codec pgtype.Codec, typeOIDs TypeOIDs, names []string) {
+	for _, name := range names {
+		m.RegisterType(&pgtype.Type{
+			Codec: codec,
+			Name:  name,
+			OID:   typeOIDs.OID,
+		})
+	}
+
+	if typeOIDs.ArrayOID == 0 {
+		return
+	}
+	elementType, _ := m.TypeForOID(typeOIDs.OID)
+	arrayCodec := &pgtype.ArrayCodec{
+		ElementType: elementType,
+	}
+	for _, name := range names {
+		m.RegisterType(&pgtype.Type{
+			Codec: arrayCodec,
+			Name:  arrayTypeName(name),
+			OID:   typeOIDs.ArrayOID,
+		})
+	}
+}
+
+// arrayTypeName returns the name of the array type of the type name, which may
+// be schema-qualified.
+func arrayTypeName(name string) string {
+	if schema, name, ok := strings.Cut(name, "."); ok {
+		return schema + "._" + name
+	}
+	return "_" + name
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/alecthomas/assert/v2"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxtest"
 	"github.com/twpayne/go-geos"
 
@@ -23,3 +24,203 @@ func init() {
 		assert.NoError(tb, pgxgeos.Register(ctx, conn, geos.NewContext()))
 	}
 }
+
+func TestRegisterWithOptions(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		report, err := pgxgeos.RegisterWithOptions(ctx, conn, pgxgeos.WithRequiredTypes(pgxgeos.AllTypes))
+		assert.NoError(tb, err)
+		assert.Equal(tb, &pgxgeos.Report{Registered: pgxgeos.AllTypes}, report)
+	})
+}
+
+func TestRegisterWithOptionsMissingTypes(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		_, err := conn.Exec(ctx, "set search_path to pg_catalog")
+		assert.NoError(tb, err)
+
+		report, err := pgxgeos.RegisterWithOptions(ctx, conn)
+		assert.NoError(tb, err)
+		assert.Equal(tb, &pgxgeos.Report{Missing: pgxgeos.AllTypes}, report)
+
+		_, err = pgxgeos.RegisterWithOptions(ctx, conn, pgxgeos.WithRequiredTypes(pgxgeos.TypeGeometry))
+		assert.IsError(tb, err, pgxgeos.ErrTypeNotFound)
+		assert.EqualError(tb, err, "geometry: type not found")
+	})
+}
+
+func TestRegisterTypeMap(t *testing.T) {
+	m := pgtype.NewMap()
+	report, err := pgxgeos.RegisterTypeMap(m, pgxgeos.OIDs{
+		Box2D:    pgxgeos.TypeOIDs{OID: 100001},
+		Geometry: pgxgeos.TypeOIDs{OID: 100002, ArrayOID: 100003},
+	}, pgxgeos.WithGEOSContext(geos.NewContext()))
+	assert.NoError(t, err)
+	assert.Equal(t, pgxgeos.TypeBox2D|pgxgeos.TypeGeometry, report.Registered)
+
+	geom := mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(4326)
+	buf, err := m.Encode(100002, pgtype.BinaryFormatCode, geom, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, geom.ToEWKBWithSRID(), buf)
+	var actualGeom *geos.Geom
+	assert.NoError(t, m.Scan(100002, pgtype.BinaryFormatCode, buf, &actualGeom))
+	assert.Equal(t, geom.ToEWKBWithSRID(), actualGeom.ToEWKBWithSRID())
+
+	var actualBox2D geos.Box2D
+	assert.NoError(t, m.Scan(100001, pgtype.TextFormatCode, []byte("BOX(1 2,3 4)"), &actualBox2D))
+	assert.Equal(t, *geos.NewBox2D(1, 2, 3, 4), actualBox2D)
+
+	_, err = pgxgeos.RegisterTypeMap(pgtype.NewMap(), pgxgeos.OIDs{}, pgxgeos.WithRequiredTypes(pgxgeos.TypeGeometry))
+	assert.IsError(t, err, pgxgeos.ErrTypeNotFound)
+}
+
+func TestLoadOIDs(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		oids, err := pgxgeos.LoadOIDs(ctx, conn)
+		assert.NoError(tb, err)
+		assert.NotZero(tb, oids.Geometry.OID)
+		assert.SliceContains(tb, oids.GeodeticSRIDs, 4326)
+		assert.SliceContains(tb, oids.GeodeticSRIDs, 4267)
+		assert.NotSliceContains(tb, oids.GeodeticSRIDs, 3857)
+
+		m := pgtype.NewMap()
+		m.RegisterType(&pgtype.Type{
+			Codec: pgxgeos.NewGeometryCodec(geos.NewContext()),
+			Name:  "geometry",
+			OID:   oids.Geometry.OID,
+		})
+		var src []byte
+		assert.NoError(tb, conn.QueryRow(ctx, "select 'SRID=4326;POINT(1 2)'::geometry::bytea").Scan(&src))
+		var actual *geos.Geom
+		assert.NoError(tb, m.Scan(oids.Geometry.OID, pgtype.BinaryFormatCode, src, &actual))
+		assert.Equal(tb, mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(4326).ToEWKBWithSRID(), actual.ToEWKBWithSRID())
+	})
+}
+
+func TestTypeString(t *testing.T) {
+	assert.Equal(t, "", pgxgeos.Type(0).String())
+	assert.Equal(t, "box2d", pgxgeos.TypeBox2D.String())
+	assert.Equal(t, "box2d,box3d,geography,geometry,geometry_dump,valid_detail", pgxgeos.AllTypes.String())
+}
+
+func TestRegisterWithOptionsSchemaQualified(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options func(schema string) []pgxgeos.Option
+	}{
+		{
+			name: "extension",
+			options: func(string) []pgxgeos.Option {
+				return []pgxgeos.Option{pgxgeos.WithExtension("postgis")}
+			},
+		},
+		{
+			name: "schema",
+			options: func(schema string) []pgxgeos.Option {
+				return []pgxgeos.Option{pgxgeos.WithSchema(schema)}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+				tb.Helper()
+				var schema string
+				assert.NoError(tb, conn.QueryRow(ctx, "select extnamespace::regnamespace::text from pg_extension where extname = 'postgis'").Scan(&schema))
+				_, err := conn.Exec(ctx, "set search_path to pg_catalog")
+				assert.NoError(tb, err)
+
+				report, err := pgxgeos.RegisterWithOptions(ctx, conn, tc.options(schema)...)
+				assert.NoError(tb, err)
+				assert.Equal(tb, &pgxgeos.Report{Registered: pgxgeos.AllTypes}, report)
+
+				for _, name := range []string{"geometry", schema + ".geometry"} {
+					_, ok := conn.TypeMap().TypeForName(name)
+					assert.True(tb, ok)
+				}
+
+				var actual *geos.Geom
+				assert.NoError(tb, conn.QueryRow(ctx, "select "+schema+".ST_SetSRID('POINT(1 2)'::"+schema+".geometry, 4326)").Scan(&actual))
+				assert.Equal(tb, mustNewGeomFromWKT(tb, "POINT(1 2)").SetSRID(4326).ToEWKBWithSRID(), actual.ToEWKBWithSRID())
+			})
+		})
+	}
+}
+
+func TestRegisterWithOptionsTypes(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		report, err := pgxgeos.RegisterWithOptions(ctx, conn,
+			pgxgeos.WithTypes(pgxgeos.TypeGeometry),
+			pgxgeos.WithTypeAlias("geom", "geometry"),
+		)
+		assert.NoError(tb, err)
+		assert.Equal(tb, &pgxgeos.Report{Registered: pgxgeos.TypeGeometry}, report)
+		geomType, ok := conn.TypeMap().TypeForName("geom")
+		assert.True(tb, ok)
+		geometryType, ok := conn.TypeMap().TypeForName("geometry")
+		assert.True(tb, ok)
+		assert.Equal(tb, geometryType.OID, geomType.OID)
+	})
+}
+
+func TestRegisterWithOptionsDefaultSRID(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		_, err := pgxgeos.RegisterWithOptions(ctx, conn, pgxgeos.WithDefaultSRID(4326))
+		assert.NoError(tb, err)
+		for _, tc := range []struct {
+			geom         *geos.Geom
+			expectedSRID int
+		}{
+			{geom: mustNewGeomFromWKT(tb, "POINT(1 2)"), expectedSRID: 4326},
+			{geom: mustNewGeomFromWKT(tb, "POINT(1 2)").SetSRID(3857), expectedSRID: 3857},
+		} {
+			var actualSRID int
+			assert.NoError(tb, conn.QueryRow(ctx, "select ST_SRID($1::geometry)", tc.geom).Scan(&actualSRID))
+			assert.Equal(tb, tc.expectedSRID, actualSRID)
+		}
+	})
+}
+
+func TestRegisterWithOptionsValidationPolicy(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		bowtie := mustNewGeomFromWKT(tb, "POLYGON((0 0,1 1,1 0,0 1,0 0))")
+
+		_, err := pgxgeos.RegisterWithOptions(ctx, conn, pgxgeos.WithValidationPolicy(pgxgeos.ValidationReject))
+		assert.NoError(tb, err)
+		var actual *geos.Geom
+		assert.IsError(tb, conn.QueryRow(ctx, "select $1::geometry", bowtie).Scan(&actual), pgxgeos.ErrInvalidGeometry)
+		var actualBox2D geos.Box2D
+		assert.IsError(tb, conn.QueryRow(ctx, "select $1::box2d", geos.NewBox2D(3, 4, 1, 2)).Scan(&actualBox2D), pgxgeos.ErrInvalidGeometry)
+
+		_, err = pgxgeos.RegisterWithOptions(ctx, conn, pgxgeos.WithValidationPolicy(pgxgeos.ValidationMakeValid))
+		assert.NoError(tb, err)
+		assert.NoError(tb, conn.QueryRow(ctx, "select $1::geometry", bowtie).Scan(&actual))
+		assert.True(tb, actual.IsValid())
+		assert.NoError(tb, conn.QueryRow(ctx, "select $1::box2d", geos.NewBox2D(3, 4, 1, 2)).Scan(&actualBox2D))
+		assert.Equal(tb, *geos.NewBox2D(1, 2, 3, 4), actualBox2D)
+	})
+}
+
+func TestRegisterWithOptionsScanHook(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		var scanned []any
+		_, err := pgxgeos.RegisterWithOptions(ctx, conn, pgxgeos.WithScanHook(func(value any) error {
+			scanned = append(scanned, value)
+			return nil
+		}))
+		assert.NoError(tb, err)
+
+		var actualGeom *geos.Geom
+		assert.NoError(tb, conn.QueryRow(ctx, "select 'POINT(1 2)'::geometry").Scan(&actualGeom))
+		var actualBox2D geos.Box2D
+		assert.NoError(tb, conn.QueryRow(ctx, "select 'BOX(1 2,3 4)'::box2d").Scan(&actualBox2D))
+		assert.Equal(tb, 2, len(scanned))
+		assert.True(tb, scanned[0] == any(actualGeom))
+		assert.Equal(tb, actualBox2D, *scanned[1].(*geos.Box2D)) //nolint:forcetypeassert
+	})
+}
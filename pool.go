@@ -0,0 +1,111 @@
+package pgxgeos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twpayne/go-geos"
+)
+
+// extensionOIDSQL is a query returning the OID of the extension named $1, or
+// zero if it does not exist.
+const extensionOIDSQL = `select coalesce((select oid from pg_extension where extname = $1), 0)`
+
+// A Registrar registers codecs for [github.com/twpayne/go-geos] types on new
+// connections. It looks up the PostGIS OIDs on the first connection and
+// caches them, so subsequent connections only require a single cheap query.
+// Whichever types are found are cached, unless a required type is missing, in
+// which case an error is returned and the OIDs are looked up again on the next
+// connection.
+//
+// On each connection, the Registrar checks the OID of the PostGIS extension.
+// If it has changed, because the extension was dropped and recreated, then the
+// cached OIDs are discarded and looked up again.
+type Registrar struct {
+	options      *options
+	mutex        sync.Mutex
+	oids         *OIDs
+	extensionOID uint32
+}
+
+// NewRegistrar returns a new Registrar that uses geosContext and opts.
+func NewRegistrar(geosContext *geos.Context, opts ...Option) *Registrar {
+	return &Registrar{
+		options: newOptions(append([]Option{WithGEOSContext(geosContext)}, opts...)),
+	}
+}
+
+// ConfigurePool configures config to register codecs for
+// [github.com/twpayne/go-geos] types on each new connection. Any existing
+// config.AfterConnect is called before the codecs are registered. It returns
+// the Registrar used, which can be used to invalidate the cached OIDs.
+func ConfigurePool(config *pgxpool.Config, geosContext *geos.Context, opts ...Option) *Registrar {
+	registrar := NewRegistrar(geosContext, opts...)
+	afterConnect := config.AfterConnect
+	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		if afterConnect != nil {
+			if err := afterConnect(ctx, conn); err != nil {
+				return err
+			}
+		}
+		return registrar.AfterConnect(ctx, conn)
+	}
+	return registrar
+}
+
+// AfterConnect registers codecs for [github.com/twpayne/go-geos] types on
+// conn, looking up the PostGIS OIDs only if they are not already cached or the
+// PostGIS extension has changed. Its
+// signature matches [github.com/jackc/pgx/v5/pgxpool.Config.AfterConnect].
+func (r *Registrar) AfterConnect(ctx context.Context, conn *pgx.Conn) error {
+	oids, err := r.loadOIDs(ctx, conn)
+	if err != nil {
+		return err
+	}
+	_, err = registerOIDs(conn.TypeMap(), oids, r.options)
+	return err
+}
+
+// Invalidate discards the cached OIDs so that they are looked up again on
+// the next connection. Recreating the PostGIS extension is detected
+// automatically, so Invalidate is only needed when the PostGIS types change in
+// some other way. Existing connections retain the old OIDs, so pools should
+// also be reset, for example with
+// [github.com/jackc/pgx/v5/pgxpool.Pool.Reset].
+func (r *Registrar) Invalidate() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.oids = nil
+}
+
+// loadOIDs returns the cached OIDs, looking them up on conn if needed because
+// they are not cached or the PostGIS extension has changed.
+func (r *Registrar) loadOIDs(ctx context.Context, conn *pgx.Conn) (OIDs, error) {
+	extension := r.options.extension
+	if extension == "" {
+		extension = "postgis"
+	}
+	var extensionOID uint32
+	if err := conn.QueryRow(ctx, extensionOIDSQL, extension).Scan(&extensionOID); err != nil {
+		return OIDs{}, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.oids != nil && r.extensionOID == extensionOID {
+		return *r.oids, nil
+	}
+	oids, err := loadOIDs(ctx, conn, r.options)
+	if err != nil {
+		return oids, err
+	}
+	if missing := r.options.required &^ oids.types(); missing != 0 {
+		return oids, fmt.Errorf("%s: %w", missing, ErrTypeNotFound)
+	}
+	r.oids = &oids
+	r.extensionOID = extensionOID
+	return oids, nil
+}
@@ -0,0 +1,137 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+// A typeQueryCounter counts queries that look up PostGIS types.
+type typeQueryCounter struct {
+	count atomic.Int64
+}
+
+func (c *typeQueryCounter) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if strings.Contains(data.SQL, "pg_type") {
+		c.count.Add(1)
+	}
+	return ctx
+}
+
+func (c *typeQueryCounter) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+}
+
+func TestConfigurePool(t *testing.T) {
+	ctx := context.Background()
+
+	config, err := pgxpool.ParseConfig("")
+	assert.NoError(t, err)
+	var counter typeQueryCounter
+	config.ConnConfig.Tracer = &counter
+	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, "create extension if not exists postgis")
+		return err
+	}
+	registrar := pgxgeos.ConfigurePool(config, geos.NewContext())
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	var conns []*pgxpool.Conn
+	for range 3 {
+		conn, err := pool.Acquire(ctx)
+		assert.NoError(t, err)
+		conns = append(conns, conn)
+
+		geom := mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(4326)
+		var actual *geos.Geom
+		assert.NoError(t, conn.QueryRow(ctx, "select $1::geometry", geom).Scan(&actual))
+		assert.Equal(t, geom.ToEWKBWithSRID(), actual.ToEWKBWithSRID())
+	}
+	assert.Equal(t, 1, counter.count.Load())
+
+	registrar.Invalidate()
+	pool.Reset()
+	for _, conn := range conns {
+		conn.Release()
+	}
+
+	conn, err := pool.Acquire(ctx)
+	assert.NoError(t, err)
+	box2D := geos.NewBox2D(1, 2, 3, 4)
+	var actual geos.Box2D
+	assert.NoError(t, conn.QueryRow(ctx, "select $1::box2d", box2D).Scan(&actual))
+	assert.Equal(t, *box2D, actual)
+	assert.Equal(t, 2, counter.count.Load())
+
+	// Recreating the extension changes the OIDs, which is detected on the
+	// next connection.
+	_, err = conn.Exec(ctx, "drop extension postgis cascade")
+	assert.NoError(t, err)
+	_, err = conn.Exec(ctx, "create extension postgis")
+	assert.NoError(t, err)
+	pool.Reset()
+	conn.Release()
+
+	conn, err = pool.Acquire(ctx)
+	assert.NoError(t, err)
+	defer conn.Release()
+	geom := mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(4326)
+	var actualGeom *geos.Geom
+	assert.NoError(t, conn.QueryRow(ctx, "select $1::geometry", geom).Scan(&actualGeom))
+	assert.Equal(t, geom.ToEWKBWithSRID(), actualGeom.ToEWKBWithSRID())
+	assert.Equal(t, 3, counter.count.Load())
+}
+
+func TestRegistrarMissingTypes(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name            string
+		opts            []pgxgeos.Option
+		expectedErr     error
+		expectedLookups int64
+	}{
+		{
+			name:            "optional",
+			expectedLookups: 1,
+		},
+		{
+			name:            "required",
+			opts:            []pgxgeos.Option{pgxgeos.WithRequiredTypes(pgxgeos.TypeGeometry)},
+			expectedErr:     pgxgeos.ErrTypeNotFound,
+			expectedLookups: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := pgx.ParseConfig("")
+			assert.NoError(t, err)
+			var counter typeQueryCounter
+			config.Tracer = &counter
+			conn, err := pgx.ConnectConfig(ctx, config)
+			assert.NoError(t, err)
+			defer conn.Close(ctx)
+
+			// No PostGIS types exist in pg_catalog.
+			registrar := pgxgeos.NewRegistrar(geos.NewContext(), append([]pgxgeos.Option{pgxgeos.WithSchema("pg_catalog")}, tc.opts...)...)
+			for range 2 {
+				err := registrar.AfterConnect(ctx, conn)
+				if tc.expectedErr != nil {
+					assert.IsError(t, err, tc.expectedErr)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+			assert.Equal(t, tc.expectedLookups, counter.count.Load())
+		})
+	}
+}
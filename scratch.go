@@ -0,0 +1,41 @@
+package pgxgeos
+
+import (
+	"encoding/hex"
+	"sync"
+)
+
+// maxScratchBufferCap is the largest capacity of scratch buffers returned to
+// scratchBufferPool, so that a single large geometry does not pin memory.
+const maxScratchBufferCap = 1 << 20
+
+// scratchBufferPool is a pool of scratch buffers used when converting between
+// EWKB and hex-encoded EWKB.
+var scratchBufferPool = sync.Pool{
+	New: func() any {
+		return new([]byte)
+	},
+}
+
+// getScratchBuffer returns an empty scratch buffer from scratchBufferPool.
+func getScratchBuffer() *[]byte {
+	return scratchBufferPool.Get().(*[]byte) //nolint:forcetypeassert
+}
+
+// putScratchBuffer returns scratch to scratchBufferPool. Its contents must not
+// be used afterwards.
+func putScratchBuffer(scratch *[]byte) {
+	if cap(*scratch) > maxScratchBufferCap {
+		return
+	}
+	*scratch = (*scratch)[:0]
+	scratchBufferPool.Put(scratch)
+}
+
+// hexDecodeScratch returns src hex-decoded into scratch, which is grown as
+// needed. The result is only valid until scratch is returned to the pool.
+func hexDecodeScratch(scratch *[]byte, src []byte) ([]byte, error) {
+	data, err := hex.AppendDecode((*scratch)[:0], src)
+	*scratch = data
+	return data, err
+}
@@ -0,0 +1,211 @@
+package pgxgeos
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/twpayne/go-geos"
+)
+
+// A Geom wraps a [*github.com/twpayne/go-geos.Geom] and implements
+// [database/sql.Scanner] and [database/sql/driver.Valuer], for use with
+// packages that only understand database/sql interfaces. It is also accepted
+// by the codecs registered by [Register], so it uses the binary format with
+// pgx.
+//
+// Geom cannot scan NULL values. Use [NullGeom] for nullable columns.
+type Geom struct {
+	*geos.Geom
+}
+
+// A NullGeom is a [Geom] that may be NULL.
+type NullGeom struct {
+	Geom  *geos.Geom
+	Valid bool
+}
+
+// A Box2D wraps a [github.com/twpayne/go-geos.Box2D] and implements
+// [database/sql.Scanner] and [database/sql/driver.Valuer]. It is also
+// accepted by the codecs registered by [Register].
+type Box2D struct {
+	geos.Box2D
+}
+
+// A Box3D wraps a [github.com/twpayne/go-geos.Box3D] and implements
+// [database/sql.Scanner] and [database/sql/driver.Valuer]. It is also
+// accepted by the codecs registered by [Register].
+type Box3D struct {
+	geos.Box3D
+}
+
+// errScanNull is returned when scanning NULL into a type that cannot represent
+// it.
+var errScanNull = errors.New("cannot scan NULL")
+
+// Scan implements [database/sql.Scanner.Scan]. src may be EWKB or hex-encoded
+// EWKB.
+func (g *Geom) Scan(src any) error {
+	geom, err := geomFromSQLValue(src)
+	if err != nil {
+		return err
+	}
+	if geom == nil {
+		return fmt.Errorf("%T: %w", g, errScanNull)
+	}
+	g.Geom = geom
+	return nil
+}
+
+// Value implements [database/sql/driver.Valuer.Value]. It returns hex-encoded
+// EWKB, or nil if g is nil.
+func (g Geom) Value() (driver.Value, error) {
+	if g.Geom == nil {
+		return nil, nil
+	}
+	return hex.EncodeToString(g.ToEWKBWithSRID()), nil
+}
+
+// Scan implements [database/sql.Scanner.Scan]. src may be EWKB or hex-encoded
+// EWKB.
+func (g *NullGeom) Scan(src any) error {
+	geom, err := geomFromSQLValue(src)
+	if err != nil {
+		return err
+	}
+	g.Geom, g.Valid = geom, geom != nil
+	return nil
+}
+
+// Value implements [database/sql/driver.Valuer.Value]. It returns hex-encoded
+// EWKB, or nil if g is not valid.
+func (g NullGeom) Value() (driver.Value, error) {
+	if !g.Valid || g.Geom == nil {
+		return nil, nil
+	}
+	return hex.EncodeToString(g.Geom.ToEWKBWithSRID()), nil
+}
+
+// Scan implements [database/sql.Scanner.Scan]. src must be in text format.
+func (b *Box2D) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		return fmt.Errorf("%T: %w", b, errScanNull)
+	case []byte:
+		return decodeBox2D(&b.Box2D, src)
+	case string:
+		return decodeBox2D(&b.Box2D, []byte(src))
+	default:
+		return fmt.Errorf("%T: cannot scan %T", b, src)
+	}
+}
+
+// Value implements [database/sql/driver.Valuer.Value]. It returns b in text
+// format.
+func (b Box2D) Value() (driver.Value, error) {
+	return string(appendBox2D(nil, &b.Box2D)), nil
+}
+
+// Scan implements [database/sql.Scanner.Scan]. src must be in text format.
+func (b *Box3D) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		return fmt.Errorf("%T: %w", b, errScanNull)
+	case []byte:
+		return decodeBox3D(&b.Box3D, src)
+	case string:
+		return decodeBox3D(&b.Box3D, []byte(src))
+	default:
+		return fmt.Errorf("%T: cannot scan %T", b, src)
+	}
+}
+
+// Value implements [database/sql/driver.Valuer.Value]. It returns b in text
+// format.
+func (b Box3D) Value() (driver.Value, error) {
+	return string(appendBox3D(nil, &b.Box3D)), nil
+}
+
+// geomFromSQLValue returns the geometry in src, which may be nil, EWKB, or
+// hex-encoded EWKB. EWKB starts with a byte order byte of 0 or 1, whereas
+// hex-encoded EWKB starts with the character '0'.
+func geomFromSQLValue(src any) (*geos.Geom, error) {
+	var ewkb []byte
+	switch src := src.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		if len(src) > 0 && src[0] == '0' {
+			var err error
+			if ewkb, err = hex.DecodeString(string(src)); err != nil {
+				return nil, err
+			}
+		} else {
+			ewkb = src
+		}
+	case string:
+		var err error
+		if ewkb, err = hex.DecodeString(src); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("cannot scan %T into geometry", src)
+	}
+	return geos.NewGeomFromWKB(ewkb)
+}
+
+// geomFromValue returns the geometry in value, which may be a
+// [*github.com/twpayne/go-geos.Geom] or one of the wrapper types. The
+// returned geometry is nil if value represents NULL.
+func geomFromValue(value any) (*geos.Geom, bool) {
+	switch value := value.(type) {
+	case *geos.Geom:
+		return value, true
+	case Geom:
+		return value.Geom, true
+	case *Geom:
+		return value.Geom, true
+	case NullGeom:
+		if !value.Valid {
+			return nil, true
+		}
+		return value.Geom, true
+	case *NullGeom:
+		if !value.Valid {
+			return nil, true
+		}
+		return value.Geom, true
+	case Geography:
+		return value.Geom, true
+	case *Geography:
+		return value.Geom, true
+	default:
+		return nil, false
+	}
+}
+
+// scanGeom sets target, which may be a [**github.com/twpayne/go-geos.Geom]
+// or a pointer to one of the wrapper types, to geom. geom is nil if the
+// scanned value is NULL.
+func scanGeom(target any, geom *geos.Geom) error {
+	switch target := target.(type) {
+	case **geos.Geom:
+		*target = geom
+	case *Geom:
+		if geom == nil {
+			return fmt.Errorf("%T: %w", target, errScanNull)
+		}
+		target.Geom = geom
+	case *NullGeom:
+		target.Geom, target.Valid = geom, geom != nil
+	case *Geography:
+		if geom == nil {
+			return fmt.Errorf("%T: %w", target, errScanNull)
+		}
+		target.Geom = geom
+	default:
+		return errors.ErrUnsupported
+	}
+	return nil
+}
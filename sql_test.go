@@ -0,0 +1,100 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestWrapperTypes(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		for _, geomType := range geomTypes {
+			t.Run(geomType, func(t *testing.T) {
+				for _, format := range []int16{
+					pgx.BinaryFormatCode,
+					pgx.TextFormatCode,
+				} {
+					tb.(*testing.T).Run(strconv.Itoa(int(format)), func(t *testing.T) { //nolint:forcetypeassert
+						geom := pgxgeos.Geom{Geom: mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(4326)}
+						var actual pgxgeos.Geom
+						assert.NoError(t, conn.QueryRow(ctx, "select $1::"+geomType, pgx.QueryResultFormats{format}, geom).Scan(&actual))
+						assert.Equal(t, geom.ToEWKBWithSRID(), actual.ToEWKBWithSRID())
+
+						assert.Error(t, conn.QueryRow(ctx, "select NULL::"+geomType, pgx.QueryResultFormats{format}).Scan(&actual))
+
+						var actualNull pgxgeos.NullGeom
+						assert.NoError(t, conn.QueryRow(ctx, "select $1::"+geomType, pgx.QueryResultFormats{format}, pgxgeos.NullGeom{}).Scan(&actualNull))
+						assert.False(t, actualNull.Valid)
+						assert.Zero(t, actualNull.Geom)
+
+						assert.NoError(t, conn.QueryRow(ctx, "select $1::"+geomType, pgx.QueryResultFormats{format}, &pgxgeos.NullGeom{Geom: geom.Geom, Valid: true}).Scan(&actualNull))
+						assert.True(t, actualNull.Valid)
+						assert.Equal(t, geom.ToEWKBWithSRID(), actualNull.Geom.ToEWKBWithSRID())
+					})
+				}
+			})
+		}
+
+		box2D := pgxgeos.Box2D{Box2D: *geos.NewBox2D(1, 2, 3, 4)}
+		var actualBox2D pgxgeos.Box2D
+		assert.NoError(t, conn.QueryRow(ctx, "select $1::box2d", box2D).Scan(&actualBox2D))
+		assert.Equal(t, box2D, actualBox2D)
+
+		box3D := pgxgeos.Box3D{Box3D: *geos.NewBox3D(1, 2, 3, 4, 5, 6)}
+		var actualBox3D pgxgeos.Box3D
+		assert.NoError(t, conn.QueryRow(ctx, "select $1::box3d", box3D).Scan(&actualBox3D))
+		assert.Equal(t, box3D, actualBox3D)
+	})
+}
+
+func TestWrapperTypesDatabaseSQL(t *testing.T) {
+	ctx := context.Background()
+
+	config, err := pgx.ParseConfig("")
+	assert.NoError(t, err)
+	conn, err := pgx.ConnectConfig(ctx, config)
+	assert.NoError(t, err)
+	_, err = conn.Exec(ctx, "create extension if not exists postgis")
+	assert.NoError(t, err)
+	assert.NoError(t, conn.Close(ctx))
+
+	// Without RegisterStdlib, the wrapper types must work through
+	// database/sql.Scanner and database/sql/driver.Valuer alone.
+	db := stdlib.OpenDB(*config)
+	defer db.Close()
+
+	for _, geomType := range geomTypes {
+		t.Run(geomType, func(t *testing.T) {
+			geom := pgxgeos.Geom{Geom: mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(4326)}
+			var actual pgxgeos.Geom
+			assert.NoError(t, db.QueryRowContext(ctx, "select $1::"+geomType, geom).Scan(&actual))
+			assert.Equal(t, geom.ToEWKBWithSRID(), actual.ToEWKBWithSRID())
+
+			var actualNull pgxgeos.NullGeom
+			assert.NoError(t, db.QueryRowContext(ctx, "select $1::"+geomType, pgxgeos.NullGeom{}).Scan(&actualNull))
+			assert.False(t, actualNull.Valid)
+		})
+	}
+
+	t.Run("box2d", func(t *testing.T) {
+		box2D := pgxgeos.Box2D{Box2D: *geos.NewBox2D(1, 2, 3, 4)}
+		var actual pgxgeos.Box2D
+		assert.NoError(t, db.QueryRowContext(ctx, "select $1::box2d", box2D).Scan(&actual))
+		assert.Equal(t, box2D, actual)
+	})
+
+	t.Run("box3d", func(t *testing.T) {
+		box3D := pgxgeos.Box3D{Box3D: *geos.NewBox3D(1, 2, 3, 4, 5, 6)}
+		var actual pgxgeos.Box3D
+		assert.NoError(t, db.QueryRowContext(ctx, "select $1::box3d", box3D).Scan(&actual))
+		assert.Equal(t, box3D, actual)
+	})
+}
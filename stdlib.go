@@ -0,0 +1,20 @@
+package pgxgeos
+
+import (
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/twpayne/go-geos"
+)
+
+// RegisterStdlib returns an option for
+// [github.com/jackc/pgx/v5/stdlib.OpenDB] and
+// [github.com/jackc/pgx/v5/stdlib.GetConnector] that registers codecs for
+// [github.com/twpayne/go-geos] types on each new connection, using a
+// [Registrar] to cache the PostGIS OIDs.
+//
+// The stdlib driver requests unknown types, including all PostGIS types, in
+// text format and returns them as strings, so geometry and geography values
+// are returned as hex-encoded EWKB and box2d and box3d values are returned in
+// their text form. Use [Geom], [NullGeom], [Box2D], and [Box3D] to scan them.
+func RegisterStdlib(geosContext *geos.Context, opts ...Option) stdlib.OptionOpenDB {
+	return stdlib.OptionAfterConnect(NewRegistrar(geosContext, opts...).AfterConnect)
+}
@@ -0,0 +1,60 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestRegisterStdlib(t *testing.T) {
+	ctx := context.Background()
+
+	config, err := pgx.ParseConfig("")
+	assert.NoError(t, err)
+	conn, err := pgx.ConnectConfig(ctx, config)
+	assert.NoError(t, err)
+	_, err = conn.Exec(ctx, "create extension if not exists postgis")
+	assert.NoError(t, err)
+	assert.NoError(t, conn.Close(ctx))
+
+	db := stdlib.OpenDB(*config, pgxgeos.RegisterStdlib(geos.NewContext()))
+	defer db.Close()
+
+	for _, geomType := range geomTypes {
+		t.Run(geomType, func(t *testing.T) {
+			expected := mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(4326)
+			var actualHex string
+			assert.NoError(t, db.QueryRowContext(ctx, "select ST_SetSRID('POINT(1 2)'::"+geomType+", 4326)").Scan(&actualHex))
+			actualEWKB, err := hex.DecodeString(actualHex)
+			assert.NoError(t, err)
+			assert.Equal(t, expected.ToEWKBWithSRID(), actualEWKB)
+
+			var actual pgxgeos.Geom
+			assert.NoError(t, db.QueryRowContext(ctx, "select ST_SetSRID('POINT(1 2)'::"+geomType+", 4326)").Scan(&actual))
+			assert.Equal(t, expected.ToEWKBWithSRID(), actual.ToEWKBWithSRID())
+
+			var actualNull pgxgeos.NullGeom
+			assert.NoError(t, db.QueryRowContext(ctx, "select NULL::"+geomType).Scan(&actualNull))
+			assert.False(t, actualNull.Valid)
+		})
+	}
+
+	t.Run("box2d", func(t *testing.T) {
+		var actual string
+		assert.NoError(t, db.QueryRowContext(ctx, "select 'BOX(1 2,3 4)'::box2d").Scan(&actual))
+		assert.Equal(t, "BOX(1 2,3 4)", actual)
+	})
+
+	t.Run("box3d", func(t *testing.T) {
+		var actual string
+		assert.NoError(t, db.QueryRowContext(ctx, "select 'BOX3D(1 2 3,4 5 6)'::box3d").Scan(&actual))
+		assert.Equal(t, "BOX3D(1 2 3,4 5 6)", actual)
+	})
+}
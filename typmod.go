@@ -0,0 +1,222 @@
+package pgxgeos
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// A GeometryType is a PostGIS geometry type, as used in typmods and EWKB.
+type GeometryType uint32
+
+// Geometry types. GeometryTypeAny is used in typmods that allow any geometry
+// type, for example plain geometry.
+const (
+	GeometryTypeAny GeometryType = iota
+	GeometryTypePoint
+	GeometryTypeLineString
+	GeometryTypePolygon
+	GeometryTypeMultiPoint
+	GeometryTypeMultiLineString
+	GeometryTypeMultiPolygon
+	GeometryTypeGeometryCollection
+	GeometryTypeCircularString
+	GeometryTypeCompoundCurve
+	GeometryTypeCurvePolygon
+	GeometryTypeMultiCurve
+	GeometryTypeMultiSurface
+	GeometryTypePolyhedralSurface
+	GeometryTypeTriangle
+	GeometryTypeTIN
+)
+
+// geometryTypeNames are the PostGIS names of the geometry types, indexed by
+// geometry type.
+var geometryTypeNames = []string{
+	"Geometry",
+	"Point",
+	"LineString",
+	"Polygon",
+	"MultiPoint",
+	"MultiLineString",
+	"MultiPolygon",
+	"GeometryCollection",
+	"CircularString",
+	"CompoundCurve",
+	"CurvePolygon",
+	"MultiCurve",
+	"MultiSurface",
+	"PolyhedralSurface",
+	"Triangle",
+	"Tin",
+}
+
+// A GeometryTypmod is a decoded PostGIS geometry or geography typmod, for
+// example that of geometry(PointZ, 4326). A GeometryType of GeometryTypeAny
+// or a zero SRID means that any geometry type or SRID is allowed.
+type GeometryTypmod struct {
+	GeometryType GeometryType
+	SRID         int
+	Z            bool
+	M            bool
+}
+
+// A TypmodError is returned when a geometry does not match the typmod of the
+// type that it is encoded as, for example a domain created as
+// geometry(Point, 4326). Err is one of [ErrGeometryTypeMismatch],
+// [ErrSRIDMismatch], or [ErrDimensionMismatch].
+type TypmodError struct {
+	Expected string
+	Actual   string
+	Err      error
+}
+
+// Error implements [error.Error].
+func (e *TypmodError) Error() string {
+	return fmt.Sprintf("expected %s, got %s: %v", e.Expected, e.Actual, e.Err)
+}
+
+// Unwrap returns e.Err.
+func (e *TypmodError) Unwrap() error {
+	return e.Err
+}
+
+// ParseTypmod decodes typmod, as encoded by PostGIS. A typmod of -1, meaning
+// no typmod, decodes to the zero GeometryTypmod.
+func ParseTypmod(typmod int32) (GeometryTypmod, error) {
+	switch {
+	case typmod == -1:
+		return GeometryTypmod{}, nil
+	case typmod < 0:
+		return GeometryTypmod{}, fmt.Errorf("%d: %w", typmod, ErrInvalidTypmod)
+	}
+	geometryType := GeometryType((typmod & 0xfc) >> 2)
+	if geometryType > GeometryTypeTIN {
+		return GeometryTypmod{}, fmt.Errorf("%d: %w", typmod, ErrInvalidTypmod)
+	}
+	return GeometryTypmod{
+		GeometryType: geometryType,
+		SRID:         int(((typmod & 0x0fffff00) - (typmod & 0x10000000)) >> 8),
+		Z:            typmod&2 != 0,
+		M:            typmod&1 != 0,
+	}, nil
+}
+
+// FieldTypmod returns the typmod of the field described by fd, whose type must
+// be registered on m as geometry or geography, or a domain over them. Fields
+// of domain types have no typmod, so the domain's typmod is returned. It
+// returns an error wrapping [ErrNotGeometry] if it is not.
+func FieldTypmod(m *pgtype.Map, fd pgconn.FieldDescription) (GeometryTypmod, error) {
+	if t, ok := m.TypeForOID(fd.DataTypeOID); ok {
+		if codec, ok := t.Codec.(*geometryCodec); ok {
+			if fd.TypeModifier < 0 && codec.typmod != nil {
+				return *codec.typmod, nil
+			}
+			return ParseTypmod(fd.TypeModifier)
+		}
+	}
+	return GeometryTypmod{}, fmt.Errorf("%s: %w", fd.Name, ErrNotGeometry)
+}
+
+// String returns the PostGIS name of t.
+func (t GeometryType) String() string {
+	if t > GeometryTypeTIN {
+		return strconv.FormatUint(uint64(t), 10)
+	}
+	return geometryTypeNames[t]
+}
+
+// String returns t in the form used by PostGIS, for example PointZ,4326.
+func (t GeometryTypmod) String() string {
+	s := t.GeometryType.String() + dimensionsSuffix(t.Z, t.M)
+	if t.SRID != 0 {
+		s += "," + strconv.Itoa(t.SRID)
+	}
+	return s
+}
+
+// apply returns ewkb coerced with coercions to match t. It returns a
+// [*TypmodError] if ewkb does not match t after coercion.
+func (t GeometryTypmod) apply(ewkb []byte, coercions Coercion) ([]byte, error) {
+	header, err := parseEWKBHeader(ewkb)
+	if err != nil {
+		return nil, err
+	}
+
+	// As in PostGIS, geometries with no SRID are given the typmod's SRID.
+	if t.SRID != 0 && header.srid != t.SRID {
+		if header.srid != 0 {
+			return nil, &TypmodError{
+				Expected: "SRID " + strconv.Itoa(t.SRID),
+				Actual:   "SRID " + strconv.Itoa(header.srid),
+				Err:      ErrSRIDMismatch,
+			}
+		}
+		ewkb = setEWKBDefaultSRID(ewkb, t.SRID)
+	}
+
+	if header.z && !t.Z || header.m && !t.M {
+		if coercions&CoerceForce2D == 0 {
+			return nil, &TypmodError{
+				Expected: "XY" + dimensionsSuffix(t.Z, t.M),
+				Actual:   "XY" + dimensionsSuffix(header.z, header.m),
+				Err:      ErrDimensionMismatch,
+			}
+		}
+		if ewkb, err = setEWKBDimensions(ewkb, t.Z, t.M); err != nil {
+			return nil, err
+		}
+		header.z = header.z && t.Z
+		header.m = header.m && t.M
+	}
+	if t.Z && !header.z || t.M && !header.m {
+		return nil, &TypmodError{
+			Expected: "XY" + dimensionsSuffix(t.Z, t.M),
+			Actual:   "XY" + dimensionsSuffix(header.z, header.m),
+			Err:      ErrDimensionMismatch,
+		}
+	}
+
+	if t.GeometryType != GeometryTypeAny && header.geometryType != t.GeometryType {
+		switch {
+		case coercions&CoerceMulti != 0 && t.GeometryType == header.geometryType.multi():
+			if ewkb, err = promoteEWKBToMulti(ewkb); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, &TypmodError{
+				Expected: t.GeometryType.String(),
+				Actual:   header.geometryType.String(),
+				Err:      ErrGeometryTypeMismatch,
+			}
+		}
+	}
+
+	return ewkb, nil
+}
+
+// multi returns the multi type of t, or GeometryTypeAny if t has none.
+func (t GeometryType) multi() GeometryType {
+	switch t {
+	case GeometryTypePoint, GeometryTypeLineString, GeometryTypePolygon:
+		return t + GeometryTypeMultiPoint - GeometryTypePoint
+	default:
+		return GeometryTypeAny
+	}
+}
+
+// dimensionsSuffix returns the suffix for the Z and M dimensions.
+func dimensionsSuffix(z, m bool) string {
+	switch {
+	case z && m:
+		return "ZM"
+	case z:
+		return "Z"
+	case m:
+		return "M"
+	default:
+		return ""
+	}
+}
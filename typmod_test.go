@@ -0,0 +1,306 @@
+package pgxgeos_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/twpayne/go-geos"
+
+	pgxgeos "github.com/twpayne/pgx-geos"
+)
+
+func TestTypmod(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		domain      string
+		wkt         string
+		srid        int
+		coercions   pgxgeos.Coercion
+		expectedErr error
+		expected    string
+	}{
+		{
+			name:     "match",
+			domain:   "point_4326",
+			wkt:      "POINT(1 2)",
+			srid:     4326,
+			expected: "SRID=4326;POINT(1 2)",
+		},
+		{
+			name:        "geometry_type_mismatch",
+			domain:      "point_4326",
+			wkt:         "LINESTRING(1 2,3 4)",
+			srid:        4326,
+			expectedErr: pgxgeos.ErrGeometryTypeMismatch,
+		},
+		{
+			name:        "srid_mismatch",
+			domain:      "point_4326",
+			wkt:         "POINT(1 2)",
+			srid:        3857,
+			expectedErr: pgxgeos.ErrSRIDMismatch,
+		},
+		{
+			name:     "missing_srid",
+			domain:   "point_4326",
+			wkt:      "POINT(1 2)",
+			expected: "SRID=4326;POINT(1 2)",
+		},
+		{
+			name:        "dimension_mismatch",
+			domain:      "point_4326",
+			wkt:         "POINT Z (1 2 3)",
+			srid:        4326,
+			expectedErr: pgxgeos.ErrDimensionMismatch,
+		},
+		{
+			name:      "coerce_force_2d",
+			domain:    "point_4326",
+			wkt:       "POINT Z (1 2 3)",
+			srid:      4326,
+			coercions: pgxgeos.CoerceForce2D,
+			expected:  "SRID=4326;POINT(1 2)",
+		},
+		{
+			name:        "missing_z",
+			domain:      "point_z_4326",
+			wkt:         "POINT(1 2)",
+			srid:        4326,
+			coercions:   pgxgeos.CoerceForce2D,
+			expectedErr: pgxgeos.ErrDimensionMismatch,
+		},
+		{
+			name:        "multi_mismatch",
+			domain:      "multipolygon_4326",
+			wkt:         "POLYGON((0 0,1 0,1 1,0 0))",
+			srid:        4326,
+			expectedErr: pgxgeos.ErrGeometryTypeMismatch,
+		},
+		{
+			name:      "coerce_multi",
+			domain:    "multipolygon_4326",
+			wkt:       "POLYGON Z ((0 0 1,1 0 1,1 1 1,0 0 1))",
+			coercions: pgxgeos.CoerceForce2D | pgxgeos.CoerceMulti,
+			expected:  "SRID=4326;MULTIPOLYGON(((0 0,1 0,1 1,0 0)))",
+		},
+		{
+			name:     "inherited",
+			domain:   "small_multipolygon_4326",
+			wkt:      "MULTIPOLYGON(((0 0,1 0,1 1,0 0)))",
+			srid:     4326,
+			expected: "SRID=4326;MULTIPOLYGON(((0 0,1 0,1 1,0 0)))",
+		},
+		{
+			name:        "inherited_mismatch",
+			domain:      "small_multipolygon_4326",
+			wkt:         "POINT(1 2)",
+			srid:        4326,
+			expectedErr: pgxgeos.ErrGeometryTypeMismatch,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+				tb.Helper()
+				tx, err := conn.Begin(ctx)
+				assert.NoError(tb, err)
+				defer func() {
+					assert.NoError(tb, tx.Rollback(ctx))
+				}()
+
+				_, err = tx.Exec(ctx, `
+					create domain point_4326 as geometry(Point, 4326);
+					create domain point_z_4326 as geometry(PointZ, 4326);
+					create domain multipolygon_4326 as geometry(MultiPolygon, 4326);
+					create domain small_multipolygon_4326 as multipolygon_4326 check (ST_Area(value) < 1);
+				`)
+				assert.NoError(tb, err)
+				_, err = pgxgeos.RegisterWithOptions(ctx, conn, pgxgeos.WithCoercions(tc.coercions))
+				assert.NoError(tb, err)
+
+				geom := mustNewGeomFromWKT(tb, tc.wkt).SetSRID(tc.srid)
+				var actual string
+				err = tx.QueryRow(ctx, "select ST_AsEWKT($1::"+tc.domain+")", geom).Scan(&actual)
+				if tc.expectedErr != nil {
+					assert.IsError(tb, err, tc.expectedErr)
+					var typmodErr *pgxgeos.TypmodError
+					assert.True(tb, errors.As(err, &typmodErr))
+					assert.Contains(tb, err.Error(), "args[0]")
+				} else {
+					assert.NoError(tb, err)
+					assert.Equal(tb, tc.expected, actual)
+				}
+			})
+		})
+	}
+}
+
+func TestTypmodRegisterTypeMap(t *testing.T) {
+	m := pgtype.NewMap()
+	_, err := pgxgeos.RegisterTypeMap(m, pgxgeos.OIDs{
+		Geometry: pgxgeos.TypeOIDs{OID: 100001},
+		Domains: []pgxgeos.DomainOIDs{
+			{
+				TypeOIDs: pgxgeos.TypeOIDs{OID: 100002},
+				Name:     "point_4326",
+				Schema:   "public",
+				Base:     pgxgeos.TypeGeometry,
+				Typmod:   4326<<8 | 1<<2, // geometry(Point, 4326)
+			},
+		},
+	}, pgxgeos.WithGEOSContext(geos.NewContext()))
+	assert.NoError(t, err)
+
+	point := mustNewGeomFromWKT(t, "POINT(1 2)").SetSRID(4326)
+	buf, err := m.Encode(100002, pgtype.BinaryFormatCode, point, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, point.ToEWKBWithSRID(), buf)
+
+	lineString := mustNewGeomFromWKT(t, "LINESTRING(1 2,3 4)").SetSRID(4326)
+	_, err = m.Encode(100002, pgtype.BinaryFormatCode, lineString, nil)
+	assert.IsError(t, err, pgxgeos.ErrGeometryTypeMismatch)
+	assert.Contains(t, err.Error(), "expected Point, got LineString")
+
+	_, err = m.Encode(100001, pgtype.BinaryFormatCode, lineString, nil)
+	assert.NoError(t, err)
+}
+
+func TestParseTypmod(t *testing.T) {
+	for _, tc := range []struct {
+		typmod      int32
+		expected    pgxgeos.GeometryTypmod
+		expectedStr string
+		expectedErr error
+	}{
+		{
+			typmod:      -1,
+			expectedStr: "Geometry",
+		},
+		{
+			typmod:      4326<<8 | 1<<2,
+			expected:    pgxgeos.GeometryTypmod{GeometryType: pgxgeos.GeometryTypePoint, SRID: 4326},
+			expectedStr: "Point,4326",
+		},
+		{
+			typmod:      2154<<8 | 6<<2 | 2,
+			expected:    pgxgeos.GeometryTypmod{GeometryType: pgxgeos.GeometryTypeMultiPolygon, SRID: 2154, Z: true},
+			expectedStr: "MultiPolygonZ,2154",
+		},
+		{
+			typmod:      2<<2 | 3,
+			expected:    pgxgeos.GeometryTypmod{GeometryType: pgxgeos.GeometryTypeLineString, Z: true, M: true},
+			expectedStr: "LineStringZM",
+		},
+		{
+			typmod:      -2,
+			expectedErr: pgxgeos.ErrInvalidTypmod,
+		},
+		{
+			typmod:      16 << 2,
+			expectedErr: pgxgeos.ErrInvalidTypmod,
+		},
+	} {
+		t.Run(strconv.Itoa(int(tc.typmod)), func(t *testing.T) {
+			actual, err := pgxgeos.ParseTypmod(tc.typmod)
+			if tc.expectedErr != nil {
+				assert.IsError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.expectedStr, actual.String())
+		})
+	}
+}
+
+func TestFieldTypmod(t *testing.T) {
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, tb testing.TB, conn *pgx.Conn) {
+		tb.Helper()
+		_, err := conn.Exec(ctx, `
+			create temporary table parcels (
+				id integer,
+				geom geometry,
+				boundary geometry(MultiPolygonZ, 2154),
+				location geography(Point)
+			)
+		`)
+		assert.NoError(tb, err)
+
+		rows, err := conn.Query(ctx, "select id, geom, boundary, location from parcels")
+		assert.NoError(tb, err)
+		fieldDescriptions := rows.FieldDescriptions()
+		rows.Close()
+		assert.NoError(tb, rows.Err())
+
+		_, err = pgxgeos.FieldTypmod(conn.TypeMap(), fieldDescriptions[0])
+		assert.IsError(tb, err, pgxgeos.ErrNotGeometry)
+
+		for i, expected := range []pgxgeos.GeometryTypmod{
+			{},
+			{GeometryType: pgxgeos.GeometryTypeMultiPolygon, SRID: 2154, Z: true},
+			{GeometryType: pgxgeos.GeometryTypePoint, SRID: 4326},
+		} {
+			actual, err := pgxgeos.FieldTypmod(conn.TypeMap(), fieldDescriptions[i+1])
+			assert.NoError(tb, err)
+			assert.Equal(tb, expected, actual)
+		}
+	})
+}
+
+func TestFieldTypmodDomain(t *testing.T) {
+	const domainOID = 100005
+	m := pgtype.NewMap()
+	_, err := pgxgeos.RegisterTypeMap(m, pgxgeos.OIDs{
+		Geometry: pgxgeos.TypeOIDs{OID: geometryTestOID},
+		Domains: []pgxgeos.DomainOIDs{
+			{
+				TypeOIDs: pgxgeos.TypeOIDs{OID: domainOID},
+				Name:     "location",
+				Schema:   "public",
+				Base:     pgxgeos.TypeGeometry,
+				Typmod:   4326<<8 | int32(pgxgeos.GeometryTypePoint)<<2,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	actual, err := pgxgeos.FieldTypmod(m, pgconn.FieldDescription{Name: "location", DataTypeOID: domainOID, TypeModifier: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, pgxgeos.GeometryTypmod{GeometryType: pgxgeos.GeometryTypePoint, SRID: 4326}, actual)
+
+	actual, err = pgxgeos.FieldTypmod(m, pgconn.FieldDescription{Name: "geom", DataTypeOID: geometryTestOID, TypeModifier: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, pgxgeos.GeometryTypmod{}, actual)
+}
+
+func TestTypmodDomainEncodeSRID(t *testing.T) {
+	const domainOID = 100005
+	m := pgtype.NewMap()
+	_, err := pgxgeos.RegisterTypeMap(m, pgxgeos.OIDs{
+		Geometry: pgxgeos.TypeOIDs{OID: geometryTestOID},
+		Domains: []pgxgeos.DomainOIDs{
+			{
+				TypeOIDs: pgxgeos.TypeOIDs{OID: domainOID},
+				Name:     "point_4326",
+				Schema:   "public",
+				Base:     pgxgeos.TypeGeometry,
+				Typmod:   4326<<8 | int32(pgxgeos.GeometryTypePoint)<<2,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	ewkb, err := m.Encode(domainOID, pgx.BinaryFormatCode, pgxgeos.EWKB(pgxgeos.NewEWKBWriter(0).AppendPointXY(nil, 1, 2)), nil)
+	assert.NoError(t, err)
+	header, err := pgxgeos.InspectEWKB(ewkb)
+	assert.NoError(t, err)
+	assert.Equal(t, 4326, header.SRID)
+
+	_, err = m.Encode(domainOID, pgx.BinaryFormatCode, pgxgeos.EWKB(pgxgeos.NewEWKBWriter(3857).AppendPointXY(nil, 1, 2)), nil)
+	assert.IsError(t, err, pgxgeos.ErrSRIDMismatch)
+}